@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// adoptionCandidate is a branch created outside of the tool, grouped across every repo that has it
+type adoptionCandidate struct {
+	BranchName string
+	Repos      []adoptionCandidateRepo
+}
+
+type adoptionCandidateRepo struct {
+	theRepo repo
+	// WorktreeDir is set when the branch is already checked out in a worktree
+	WorktreeDir string
+}
+
+func (c adoptionCandidate) repoTitles() []string {
+	titles := make([]string, len(c.Repos))
+	for i, r := range c.Repos {
+		titles[i] = r.theRepo.Title()
+	}
+	return titles
+}
+
+// scanAdoptionCandidates finds the branches of every cloned repo that are neither trunk nor tied to an effort and that
+// someone works on here, those checked out in a worktree or with commits no remote ref has. The bare clone has a local
+// branch for every branch of the remote, teammates' branches and release branches among them, so the rest are left out.
+func scanAdoptionCandidates() ([]adoptionCandidate, error) {
+	repoItems, err := fetchRepos()
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchRepos() for scanAdoptionCandidates(). Error: %w", err)
+	}
+	effortItems, err := fetchEfforts()
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchEfforts() for scanAdoptionCandidates(). Error: %w", err)
+	}
+	effortBranches := make(map[string]bool)
+	for _, item := range effortItems {
+		effortBranches[item.(effort).BranchName] = true
+	}
+
+	candidatesByBranch := make(map[string]*adoptionCandidate)
+	for _, item := range repoItems {
+		r := item.(repo)
+		exists, err := checkDirectoryExists(getBareRepoDir(r))
+		if err != nil {
+			return nil, fmt.Errorf("error, when checkDirectoryExists() for scanAdoptionCandidates(). Error: %w", err)
+		}
+		if !exists {
+			continue
+		}
+		trunk, err := getTrunkBranch(r)
+		if err != nil {
+			return nil, fmt.Errorf("error, when getTrunkBranch() for scanAdoptionCandidates(). Error: %w", err)
+		}
+		branches, err := fetchLocalBranches(r)
+		if err != nil {
+			return nil, fmt.Errorf("error, when fetchLocalBranches() for scanAdoptionCandidates(). Error: %w", err)
+		}
+		worktrees, err := fetchWorktreeBranches(r)
+		if err != nil {
+			return nil, fmt.Errorf("error, when fetchWorktreeBranches() for scanAdoptionCandidates(). Error: %w", err)
+		}
+		remoteHeads, err := fetchRemoteHeads(r)
+		if err != nil {
+			return nil, fmt.Errorf("error, when fetchRemoteHeads() for scanAdoptionCandidates(). Error: %w", err)
+		}
+		for _, branch := range branches {
+			if branch == trunk || effortBranches[branch] {
+				continue
+			}
+			if worktrees[branch] == "" {
+				unpushed, err := hasUnpushedCommits(r, branch, trunk, remoteHeads[branch])
+				if err != nil {
+					return nil, fmt.Errorf("error, when hasUnpushedCommits() for scanAdoptionCandidates(). Error: %w", err)
+				}
+				if !unpushed {
+					continue
+				}
+			}
+			candidate, ok := candidatesByBranch[branch]
+			if !ok {
+				candidate = &adoptionCandidate{BranchName: branch}
+				candidatesByBranch[branch] = candidate
+			}
+			candidate.Repos = append(candidate.Repos, adoptionCandidateRepo{
+				theRepo:     r,
+				WorktreeDir: worktrees[branch],
+			})
+		}
+	}
+
+	result := make([]adoptionCandidate, 0, len(candidatesByBranch))
+	for _, candidate := range candidatesByBranch {
+		result = append(result, *candidate)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].BranchName < result[j].BranchName
+	})
+	return result, nil
+}
+
+// fetchRemoteHeads returns the commit of every branch of the remote keyed by the branch name
+func fetchRemoteHeads(r repo) (map[string]string, error) {
+	output, err := runGitCommand(getBareRepoDir(r), "ls-remote", "--heads", "origin")
+	if err != nil {
+		return nil, fmt.Errorf("error, when listing remote branches for %s. Error: %w", r.Title(), err)
+	}
+	result := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		commit, ref, ok := strings.Cut(line, "\t")
+		if ok {
+			result[strings.TrimPrefix(ref, "refs/heads/")] = commit
+		}
+	}
+	return result, nil
+}
+
+// hasUnpushedCommits tells whether the branch has commits that aren't on the remote. remoteCommit is where the branch
+// is on the remote, empty if it isn't there, in which case the commits are checked against the remote-tracking refs and
+// trunk.
+// A remote commit this repo doesn't have means the remote moved on from a copy of the branch nobody works on here.
+func hasUnpushedCommits(r repo, branch string, trunk string, remoteCommit string) (bool, error) {
+	dir := getBareRepoDir(r)
+	if remoteCommit == "" {
+		unpushed, err := runGitCommand(dir, "rev-list", "--max-count=1", "refs/heads/"+branch, "--not", "--remotes", "refs/heads/"+trunk)
+		if err != nil {
+			return false, fmt.Errorf("error, when listing unpushed commits of %s for hasUnpushedCommits(). Error: %w", branch, err)
+		}
+		return unpushed != "", nil
+	}
+	_, err := runGitCommand(dir, "cat-file", "-e", remoteCommit+"^{commit}")
+	if err != nil {
+		return false, nil
+	}
+	_, err = runGitCommand(dir, "merge-base", "--is-ancestor", "refs/heads/"+branch, remoteCommit)
+	return err != nil, nil
+}
+
+// adoptCandidate creates an effort for the branch, existing worktrees are moved into the effort directory and the
+// rest get a new worktree for the existing branch. The worktrees are journaled like any other operation so a failure
+// partway through can be resumed or rolled back, which also removes the effort.
+func adoptCandidate(candidate adoptionCandidate, description string) error {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		description = candidate.BranchName
+	}
+	validationMsg, err := addEffort(description, candidate.BranchName)
+	if err != nil {
+		return fmt.Errorf("error, when addEffort() for adoptCandidate(). Error: %w", err)
+	}
+	if validationMsg != "" {
+		return fmt.Errorf("error, could not create effort for %s: %s", candidate.BranchName, validationMsg)
+	}
+
+	theEffort, err := fetchEffortByBranchName(candidate.BranchName)
+	if err != nil {
+		return fmt.Errorf("error, when fetchEffortByBranchName() for adoptCandidate(). Error: %w", err)
+	}
+
+	steps := make([]journalStep, 0, len(candidate.Repos)+1)
+	for _, c := range candidate.Repos {
+		steps = append(steps, journalStep{Action: journalActionAdoptWorktree, theRepo: c.theRepo, SourceDir: c.WorktreeDir})
+	}
+	steps = append(steps, journalStep{Action: journalActionPersistSelection})
+	journal, err := beginJournal(operationKindAdopt, theEffort, false, "", steps)
+	if err != nil {
+		return fmt.Errorf("error, when beginJournal() for adoptCandidate(). Error: %w", err)
+	}
+	_, err = journal.finishAfter(runJournalSteps(journal))
+	if err != nil {
+		return fmt.Errorf("error, when runJournalSteps() for adoptCandidate(). Error: %w", err)
+	}
+	return nil
+}
+
+// adoptWorktree moves the worktree the branch is checked out in into the effort directory, or adds one if it has
+// none, then pushes the branch if the remote doesn't have it as createWorktree does for every effort branch
+func adoptWorktree(theEffort effort, r repo, sourceDir string) error {
+	worktreeDir := getWorktreeDir(theEffort, r)
+	if sourceDir != "" && sourceDir != worktreeDir {
+		// a resumed step finds the worktree already moved
+		moved, err := checkDirectoryExists(worktreeDir)
+		if err != nil {
+			return fmt.Errorf("error, when checkDirectoryExists() for adoptWorktree(). Error: %w", err)
+		}
+		if !moved {
+			err = os.MkdirAll(filepath.Dir(worktreeDir), 0755)
+			if err != nil {
+				return fmt.Errorf("error, when creating effort directory for adoptWorktree(). Error: %w", err)
+			}
+			_, err = runGitCommand(getBareRepoDir(r), "worktree", "move", sourceDir, worktreeDir)
+			if err != nil {
+				return fmt.Errorf("error, when moving worktree for adoptWorktree() of repo: %s. Error: %w", r.Title(), err)
+			}
+		}
+	}
+	err := createWorktree(theEffort, r)
+	if err != nil {
+		return fmt.Errorf("error, when createWorktree() for adoptWorktree() of repo: %s. Error: %w", r.Title(), err)
+	}
+	return nil
+}
+
+// unadoptWorktree moves the worktree back to where it was before it was adopted, a worktree the adoption added is
+// removed while the branch, which existed before, is kept. A branch the adoption pushed stays on the remote.
+func unadoptWorktree(theEffort effort, r repo, sourceDir string) (string, error) {
+	worktreeDir := getWorktreeDir(theEffort, r)
+	exists, err := checkDirectoryExists(worktreeDir)
+	if err != nil {
+		return "", fmt.Errorf("error, when checkDirectoryExists() for unadoptWorktree(). Error: %w", err)
+	}
+	if !exists {
+		return "", nil
+	}
+	commandDir := getBareRepoDir(r)
+	if sourceDir != "" && sourceDir != worktreeDir {
+		_, err = runGitCommand(commandDir, "worktree", "move", worktreeDir, sourceDir)
+		if err != nil {
+			return "", fmt.Errorf("error, when moving worktree back for unadoptWorktree(). Error: %w", err)
+		}
+		return "moved worktree back to " + sourceDir, nil
+	}
+	_, err = runGitCommand(commandDir, "worktree", "remove", worktreeDir)
+	if err != nil {
+		return "", fmt.Errorf("error, when removing worktree for unadoptWorktree(). Error: %w", err)
+	}
+	return "removed worktree, kept branch " + theEffort.BranchName, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// setUpClonedRepo clones a local origin into a temp data directory the way addRepo does and returns the repo along
+// with a function that runs git in a given directory
+func setUpClonedRepo(t *testing.T) (repo, func(dir string, args ...string) string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	openTestDatabase(t)
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@test")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@test")
+	root := t.TempDir()
+	t.Setenv("HOME", root)
+	previousReposDirectory := reposDirectory
+	previousEffortsDirectory := effortsDirectory
+	reposDirectory = root + "/git_tool_data/repos/"
+	effortsDirectory = root + "/git_tool_data/efforts/"
+	t.Cleanup(func() {
+		reposDirectory = previousReposDirectory
+		effortsDirectory = previousEffortsDirectory
+	})
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		output, err := runGitCommand(dir, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+
+	origin := root + "/origin"
+	err := os.MkdirAll(origin, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(origin, "init", "-b", "main")
+	git(origin, "commit", "--allow-empty", "-m", "base")
+	git(origin, "branch", "teammate")
+
+	r := repo{Url: "git@example.com:team/app.git", TrunkBranch: "main"}
+	err = os.MkdirAll(reposDirectory, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(reposDirectory, "clone", "--bare", origin, r.getRepoDirectoryName())
+	result, err := database.Exec(`INSERT INTO repo (url, trunk_branch) VALUES (?, ?)`, r.Url, r.TrunkBranch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Id, err = result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, git
+}
+
+func Test_scanAdoptionCandidates(t *testing.T) {
+	r, git := setUpClonedRepo(t)
+	bareDir := getBareRepoDir(r)
+	root := os.Getenv("HOME")
+
+	// checked out in a worktree with nothing new on it
+	git(bareDir, "worktree", "add", "-b", "checked-out", root+"/checked-out", "main")
+	// commits the remote doesn't have, without a worktree
+	git(bareDir, "worktree", "add", "-b", "unpushed", root+"/unpushed", "main")
+	git(root+"/unpushed", "commit", "--allow-empty", "-m", "unpushed")
+	git(bareDir, "worktree", "remove", root+"/unpushed")
+	// a local branch with nothing on it that trunk doesn't have
+	git(bareDir, "branch", "stale", "main")
+
+	candidates, err := scanAdoptionCandidates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range candidates {
+		got = append(got, c.BranchName)
+	}
+	// teammate is only the copy of the remote branch the clone made
+	expected := []string{"checked-out", "unpushed"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got candidates %v, but wanted %v", got, expected)
+	}
+}
+
+func Test_adoptCandidate(t *testing.T) {
+	r, git := setUpClonedRepo(t)
+	bareDir := getBareRepoDir(r)
+	sourceDir := filepath.Join(os.Getenv("HOME"), "elsewhere")
+	git(bareDir, "worktree", "add", "-b", "ABC-1", sourceDir, "main")
+	git(sourceDir, "commit", "--allow-empty", "-m", "work")
+
+	candidate := adoptionCandidate{
+		BranchName: "ABC-1",
+		Repos:      []adoptionCandidateRepo{{theRepo: r, WorktreeDir: sourceDir}},
+	}
+	err := adoptCandidate(candidate, "billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	theEffort, err := fetchEffortByBranchName("ABC-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktreeDir := getWorktreeDir(theEffort, r)
+	exists, err := checkDirectoryExists(worktreeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("got no worktree at %s, but wanted the worktree moved there", worktreeDir)
+	}
+	selected, err := fetchSelectedReposForEffort(theEffort.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !selected[r.Id] {
+		t.Errorf("got selection %v, but wanted repo %d selected", selected, r.Id)
+	}
+
+	// rolling back the adoption puts the worktree back and removes the effort
+	var operationId int64
+	err = database.QueryRow(`SELECT id FROM operation WHERE kind = ?`, operationKindAdopt).Scan(&operationId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := fetchJournalSteps(operationId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rollbackOperation(&operationJournal{Id: operationId, Kind: operationKindAdopt, theEffort: theEffort, Steps: steps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err = checkDirectoryExists(sourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Errorf("got no worktree at %s, but wanted it moved back", sourceDir)
+	}
+	_, err = fetchEffortByBranchName("ABC-1")
+	if err == nil {
+		t.Error("got the effort after rolling back, but wanted it removed")
+	}
+	if got := git(bareDir, "rev-parse", "--abbrev-ref", "ABC-1"); got != "ABC-1" {
+		t.Errorf("got %q, but wanted the branch kept", got)
+	}
+}
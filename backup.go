@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxDatabaseBackups is how many backups are kept before the oldest ones are removed
+const maxDatabaseBackups = 10
+
+const backupFilePrefix = "data-"
+
+// backupDatabase snapshots the database then removes the oldest backups beyond maxDatabaseBackups
+func backupDatabase(reason string) (string, error) {
+	backupFile, err := snapshotDatabase(reason)
+	if err != nil {
+		return "", fmt.Errorf("error, when snapshotDatabase() for backupDatabase(). Error: %w", err)
+	}
+	err = rotateDatabaseBackups()
+	if err != nil {
+		return "", fmt.Errorf("error, when rotateDatabaseBackups() for backupDatabase(). Error: %w", err)
+	}
+	return backupFile, nil
+}
+
+// snapshotDatabase writes a backup with VACUUM INTO so it is safe to run while the database is open
+func snapshotDatabase(reason string) (string, error) {
+	err := os.MkdirAll(backupsDirectory, 0755)
+	if err != nil {
+		return "", fmt.Errorf("error, when creating backups directory for snapshotDatabase(). Error: %w", err)
+	}
+
+	backupFile := fmt.Sprintf(
+		"%s%s%s-%s.db",
+		backupsDirectory,
+		backupFilePrefix,
+		time.Now().UTC().Format("20060102T150405.000Z"),
+		strings.ReplaceAll(reason, " ", "_"),
+	)
+	_, err = database.Exec("VACUUM INTO ?", backupFile)
+	if err != nil {
+		return "", fmt.Errorf("error, when executing VACUUM INTO for snapshotDatabase(). Error: %w", err)
+	}
+	return backupFile, nil
+}
+
+func rotateDatabaseBackups() error {
+	backups, err := fetchDatabaseBackups()
+	if err != nil {
+		return fmt.Errorf("error, when fetchDatabaseBackups() for rotateDatabaseBackups(). Error: %w", err)
+	}
+	for i := maxDatabaseBackups; i < len(backups); i++ {
+		err = os.Remove(backups[i])
+		if err != nil {
+			return fmt.Errorf("error, when removing old backup %s. Error: %w", backups[i], err)
+		}
+	}
+	return nil
+}
+
+// fetchDatabaseBackups returns the backup file paths with the newest first
+func fetchDatabaseBackups() ([]string, error) {
+	entries, err := os.ReadDir(backupsDirectory)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error, when reading backups directory for fetchDatabaseBackups(). Error: %w", err)
+	}
+	var result []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), backupFilePrefix) {
+			result = append(result, backupsDirectory+entry.Name())
+		}
+	}
+	// the timestamp in the file name sorts lexically
+	sort.Sort(sort.Reverse(sort.StringSlice(result)))
+	return result, nil
+}
+
+// restoreDatabase replaces the database with the given backup, the current database is backed up first so a restore
+// can itself be undone. The backups are only rotated once the restore is done, otherwise restoring the oldest backup
+// would remove it before it is copied.
+func restoreDatabase(backupFile string) error {
+	_, err := os.Stat(backupFile)
+	if err != nil {
+		// allow just the file name of a backup to be passed in
+		backupFile = filepath.Join(backupsDirectory, backupFile)
+		_, err = os.Stat(backupFile)
+		if err != nil {
+			return fmt.Errorf("error, could not find backup file for restoreDatabase(). Error: %w", err)
+		}
+	}
+
+	_, err = snapshotDatabase("pre_restore")
+	if err != nil {
+		return fmt.Errorf("error, when snapshotDatabase() for restoreDatabase(). Error: %w", err)
+	}
+
+	err = database.Close()
+	if err != nil {
+		return fmt.Errorf("error, when closing database for restoreDatabase(). Error: %w", err)
+	}
+
+	err = copyFile(backupFile, databaseFile)
+	if err != nil {
+		return fmt.Errorf("error, when copyFile() for restoreDatabase(). Error: %w", err)
+	}
+
+	err = openDatabase()
+	if err != nil {
+		return fmt.Errorf("error, when openDatabase() for restoreDatabase(). Error: %w", err)
+	}
+
+	err = rotateDatabaseBackups()
+	if err != nil {
+		return fmt.Errorf("error, when rotateDatabaseBackups() for restoreDatabase(). Error: %w", err)
+	}
+	return nil
+}
+
+func copyFile(source string, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("error, when opening source file. Error: %w", err)
+	}
+	defer in.Close()
+
+	// writing to a temp file first then renaming so a failed copy can't leave a corrupt database behind
+	tempFile := destination + ".tmp"
+	out, err := os.Create(tempFile)
+	if err != nil {
+		return fmt.Errorf("error, when creating temp file. Error: %w", err)
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return fmt.Errorf("error, when copying file contents. Error: %w", err)
+	}
+	err = out.Close()
+	if err != nil {
+		return fmt.Errorf("error, when closing temp file. Error: %w", err)
+	}
+	err = os.Rename(tempFile, destination)
+	if err != nil {
+		return fmt.Errorf("error, when renaming temp file. Error: %w", err)
+	}
+	return nil
+}
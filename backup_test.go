@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_restoreDatabase_oldestBackup(t *testing.T) {
+	openTestDatabase(t)
+	previousBackupsDirectory := backupsDirectory
+	backupsDirectory = t.TempDir() + "/"
+	t.Cleanup(func() { backupsDirectory = previousBackupsDirectory })
+
+	// every backup has one more effort than the one before it, the names are written out since backups taken in the
+	// same millisecond would collide
+	for i := 0; i < maxDatabaseBackups; i++ {
+		_, err := database.Exec(`INSERT INTO effort (name, branch_name, description) VALUES (?, ?, '')`, fmt.Sprintf("effort-%d", i), fmt.Sprintf("ABC-%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		backupFile := fmt.Sprintf("%s%s20240101T0000%02d.000Z-test.db", backupsDirectory, backupFilePrefix, i)
+		_, err = database.Exec("VACUUM INTO ?", backupFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := fetchDatabaseBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != maxDatabaseBackups {
+		t.Fatalf("got %d backups, but wanted %d", len(backups), maxDatabaseBackups)
+	}
+	oldest := backups[len(backups)-1]
+
+	err = restoreDatabase(filepath.Base(oldest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	err = database.QueryRow(`SELECT COUNT(*) FROM effort`).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %d efforts after restoring the oldest backup, but wanted 1", count)
+	}
+
+	backups, err = fetchDatabaseBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != maxDatabaseBackups {
+		t.Errorf("got %d backups after restoring, but wanted them rotated back to %d", len(backups), maxDatabaseBackups)
+	}
+	if !strings.HasSuffix(backups[0], "-pre_restore.db") {
+		t.Errorf("got newest backup %s, but wanted the pre restore snapshot", backups[0])
+	}
+}
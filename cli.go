@@ -0,0 +1,624 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+// runCli handles the non interactive sub commands, the TUI is used when no sub command is provided
+func runCli(args []string) error {
+	// ctrl+c cancels the git commands so the repos that didn't finish are reported as cancelled
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	beginOperation(ctx)
+
+	if args[0] != "recover" {
+		journals, err := fetchUnfinishedOperations()
+		if err != nil {
+			return fmt.Errorf("error, when fetchUnfinishedOperations() for runCli(). Error: %w", err)
+		}
+		if len(journals) != 0 {
+			fmt.Fprintf(os.Stderr, "warning, %d operations were interrupted or failed before they completed, see git-tool recover\n", len(journals))
+		}
+	}
+
+	switch args[0] {
+	case "export":
+		return runExportCommand(args[1:])
+	case "import":
+		return runImportCommand(args[1:])
+	case "doctor":
+		return runDoctorCommand(args[1:])
+	case "adopt":
+		return runAdoptCommand(args[1:])
+	case "update":
+		return runUpdateCommand(args[1:])
+	case "commit":
+		return runCommitCommand(args[1:])
+	case "push":
+		return runPushCommand(args[1:])
+	case "exec":
+		return runExecCommand(args[1:])
+	case "search":
+		return runSearchCommand(args[1:])
+	case "diff":
+		return runDiffCommand(args[1:])
+	case "recover":
+		return runRecoverCommand(args[1:])
+	case "history":
+		return runHistoryCommand(args[1:])
+	case "show":
+		return runShowCommand(args[1:])
+	default:
+		return fmt.Errorf("unknown command: %s. Available commands: export, import, db, doctor, adopt, update, commit, push, exec, search, diff, recover, history, show", args[0])
+	}
+}
+
+func runExportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	outputFile := flags.String("o", "", "file to write the export to, defaults to stdout")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runExportCommand(). Error: %w", err)
+	}
+
+	output := os.Stdout
+	if *outputFile != "" {
+		output, err = os.Create(*outputFile)
+		if err != nil {
+			return fmt.Errorf("error, when creating export file for runExportCommand(). Error: %w", err)
+		}
+		defer output.Close()
+	}
+
+	err = exportState(output)
+	if err != nil {
+		return fmt.Errorf("error, when exportState() for runExportCommand(). Error: %w", err)
+	}
+	return nil
+}
+
+func runImportCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	createWorktrees := flags.Bool("worktrees", false, "also create the worktrees of every imported effort, however long ago it was last used")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runImportCommand(). Error: %w", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: git-tool import [-worktrees] <export file>")
+	}
+
+	input, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error, when opening import file for runImportCommand(). Error: %w", err)
+	}
+	defer input.Close()
+
+	err = importState(input, *createWorktrees)
+	if err != nil {
+		return fmt.Errorf("error, when importState() for runImportCommand(). Error: %w", err)
+	}
+	return nil
+}
+
+func runDbCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: git-tool db <status|rollback|backup|backups|restore>")
+	}
+	switch args[0] {
+	case "status":
+		statuses, err := fetchMigrationStatuses(databaseFiles)
+		if err != nil {
+			return fmt.Errorf("error, when fetchMigrationStatuses() for runDbCommand(). Error: %w", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Modified {
+				state = "MODIFIED since applied at " + s.AppliedAt
+			} else if s.Applied && s.AppliedAt == "" {
+				state = "applied"
+			} else if s.Applied {
+				state = "applied at " + s.AppliedAt
+			}
+			down := ""
+			if s.HasDown {
+				down = " (has down migration)"
+			}
+			fmt.Printf("%s\t%s%s\n", s.FileName, state, down)
+		}
+		return nil
+	case "rollback":
+		fileName, err := rollbackLatestMigration(databaseFiles)
+		if err != nil {
+			return fmt.Errorf("error, when rollbackLatestMigration() for runDbCommand(). Error: %w", err)
+		}
+		fmt.Printf("rolled back %s\n", fileName)
+		return nil
+	case "backup":
+		backupFile, err := backupDatabase("manual")
+		if err != nil {
+			return fmt.Errorf("error, when backupDatabase() for runDbCommand(). Error: %w", err)
+		}
+		fmt.Printf("backed up to %s\n", backupFile)
+		return nil
+	case "backups":
+		backups, err := fetchDatabaseBackups()
+		if err != nil {
+			return fmt.Errorf("error, when fetchDatabaseBackups() for runDbCommand(). Error: %w", err)
+		}
+		for _, b := range backups {
+			fmt.Println(b)
+		}
+		return nil
+	case "restore":
+		var backupFile string
+		if len(args) > 1 {
+			backupFile = args[1]
+		} else {
+			backups, err := fetchDatabaseBackups()
+			if err != nil {
+				return fmt.Errorf("error, when fetchDatabaseBackups() for runDbCommand(). Error: %w", err)
+			}
+			if len(backups) == 0 {
+				return fmt.Errorf("there are no backups to restore")
+			}
+			backupFile = backups[0]
+		}
+		err := restoreDatabase(backupFile)
+		if err != nil {
+			return fmt.Errorf("error, when restoreDatabase() for runDbCommand(). Error: %w", err)
+		}
+		fmt.Printf("restored %s\n", backupFile)
+		return nil
+	default:
+		return fmt.Errorf("unknown db command: %s. Available commands: status, rollback, backup, backups, restore", args[0])
+	}
+}
+
+func runDoctorCommand(args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fix := flags.Bool("fix", false, "fix the detected issues")
+	dryRun := flags.Bool("dry-run", false, "show what -fix would do without changing anything")
+	only := flags.String("only", "", "comma separated issue numbers to fix, defaults to all")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runDoctorCommand(). Error: %w", err)
+	}
+
+	issues, err := detectDoctorIssues()
+	if err != nil {
+		return fmt.Errorf("error, when detectDoctorIssues() for runDoctorCommand(). Error: %w", err)
+	}
+	if len(issues) == 0 {
+		fmt.Println("no issues found")
+		return nil
+	}
+
+	selected := make(map[int]bool)
+	if *only != "" {
+		for _, n := range strings.Split(*only, ",") {
+			var i int
+			i, err = strconv.Atoi(strings.TrimSpace(n))
+			if err != nil {
+				return fmt.Errorf("error, invalid issue number %s for -only", n)
+			}
+			selected[i] = true
+		}
+	}
+
+	if *fix && !*dryRun {
+		_, err = backupDatabase("pre_doctor_fix")
+		if err != nil {
+			return fmt.Errorf("error, when backupDatabase() for runDoctorCommand(), nothing was fixed. Error: %w", err)
+		}
+	}
+	var failed int
+	for i, issue := range issues {
+		number := i + 1
+		fmt.Printf("%d. %s\n   fix: %s\n", number, issue.Problem, issue.FixDescription)
+		if !*fix || (len(selected) != 0 && !selected[number]) {
+			continue
+		}
+		if *dryRun {
+			fmt.Println("   dry run, not fixed")
+			continue
+		}
+		err = fixDoctorIssue(issue)
+		if err != nil {
+			failed++
+			fmt.Printf("   failed: %v\n", err)
+		} else {
+			fmt.Println("   fixed")
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d issues could not be fixed", failed)
+	}
+	return nil
+}
+
+func runAdoptCommand(args []string) error {
+	flags := flag.NewFlagSet("adopt", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the effort to create, defaults to the branch name")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runAdoptCommand(). Error: %w", err)
+	}
+
+	candidates, err := scanAdoptionCandidates()
+	if err != nil {
+		return fmt.Errorf("error, when scanAdoptionCandidates() for runAdoptCommand(). Error: %w", err)
+	}
+
+	if flags.NArg() == 0 {
+		if len(candidates) == 0 {
+			fmt.Println("no branches found outside of efforts")
+		}
+		for _, c := range candidates {
+			fmt.Printf("%s\t%s\n", c.BranchName, strings.Join(c.repoTitles(), ", "))
+		}
+		return nil
+	}
+
+	branchName := flags.Arg(0)
+	for _, c := range candidates {
+		if c.BranchName == branchName {
+			err = adoptCandidate(c, *name)
+			if err != nil {
+				return fmt.Errorf("error, when adoptCandidate() for runAdoptCommand(). Error: %w", err)
+			}
+			fmt.Printf("adopted %s into an effort with repos: %s\n", branchName, strings.Join(c.repoTitles(), ", "))
+			return nil
+		}
+	}
+	return fmt.Errorf("branch %s was not found outside of an effort in any repo", branchName)
+}
+
+func runUpdateCommand(args []string) error {
+	flags := flag.NewFlagSet("update", flag.ContinueOnError)
+	strategy := flags.String("strategy", config.TrunkUpdateStrategy, "rebase or merge")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runUpdateCommand(). Error: %w", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: git-tool update [-strategy rebase|merge] <effort>")
+	}
+	if *strategy != trunkUpdateStrategyRebase && *strategy != trunkUpdateStrategyMerge {
+		return fmt.Errorf("-strategy must be %s or %s", trunkUpdateStrategyRebase, trunkUpdateStrategyMerge)
+	}
+
+	theEffort, err := findEffort(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error, when findEffort() for runUpdateCommand(). Error: %w", err)
+	}
+	results, err := updateEffortFromTrunk(theEffort, *strategy)
+	if err != nil {
+		return fmt.Errorf("error, when updateEffortFromTrunk() for runUpdateCommand(). Error: %w", err)
+	}
+	fmt.Println(formatRepoResults("Update from trunk for "+theEffort.Name, results))
+	notUpdated := len(results) - countRepoOutcome(results, repoOutcomeSucceeded)
+	if notUpdated != 0 {
+		return fmt.Errorf("%d of %d repos were not updated", notUpdated, len(results))
+	}
+	return nil
+}
+
+func runCommitCommand(args []string) error {
+	flags := flag.NewFlagSet("commit", flag.ContinueOnError)
+	message := flags.String("m", "", "commit message, the ticket id is prefixed automatically")
+	stageAll := flags.Bool("a", false, "stage all changes before committing")
+	onlyRepos := flags.String("repos", "", "comma separated repos to commit, defaults to every repo with changes")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runCommitCommand(). Error: %w", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: git-tool commit [-m message] [-a] [-repos a,b] <effort>")
+	}
+
+	theEffort, err := findEffort(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error, when findEffort() for runCommitCommand(). Error: %w", err)
+	}
+	changes, err := fetchEffortWorktreeChanges(theEffort)
+	if err != nil {
+		return fmt.Errorf("error, when fetchEffortWorktreeChanges() for runCommitCommand(). Error: %w", err)
+	}
+
+	// without a message only the changes are shown
+	if *message == "" {
+		for _, c := range changes {
+			fmt.Printf("%s: %d staged, %d unstaged\n", c.theRepo.Title(), len(c.Staged), len(c.Unstaged))
+			for _, path := range c.Staged {
+				fmt.Printf("    staged:   %s\n", path)
+			}
+			for _, path := range c.Unstaged {
+				fmt.Printf("    unstaged: %s\n", path)
+			}
+		}
+		return nil
+	}
+
+	if *onlyRepos != "" {
+		included := make(map[string]bool)
+		for _, title := range strings.Split(*onlyRepos, ",") {
+			included[strings.TrimSpace(title)] = true
+		}
+		for i := range changes {
+			changes[i].Selected = included[changes[i].theRepo.Title()]
+		}
+	}
+
+	results, validationMsg := commitEffort(theEffort, changes, *message, *stageAll)
+	if validationMsg != "" {
+		return fmt.Errorf("%s", validationMsg)
+	}
+	fmt.Println(formatRepoResults("Commit for "+theEffort.Name, results))
+	failed := countRepoOutcome(results, repoOutcomeFailed)
+	if failed != 0 {
+		return fmt.Errorf("%d of %d repos failed to commit", failed, len(results))
+	}
+	return nil
+}
+
+func runPushCommand(args []string) error {
+	flags := flag.NewFlagSet("push", flag.ContinueOnError)
+	forceWithLease := flags.Bool("force-with-lease", false, "push with --force-with-lease, needed after updating from trunk with rebase")
+	skipChecks := flags.Bool("skip-checks", false, "do not run the pre-push commands")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runPushCommand(). Error: %w", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: git-tool push [-force-with-lease] [-skip-checks] <effort>")
+	}
+
+	theEffort, err := findEffort(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error, when findEffort() for runPushCommand(). Error: %w", err)
+	}
+	results, err := pushEffort(theEffort, *forceWithLease, !*skipChecks)
+	if err != nil {
+		return fmt.Errorf("error, when pushEffort() for runPushCommand(). Error: %w", err)
+	}
+	fmt.Println(formatRepoResults("Push for "+theEffort.Name, results))
+	notPushed := len(results) - countRepoOutcome(results, repoOutcomeSucceeded)
+	if notPushed != 0 {
+		return fmt.Errorf("%d of %d repos were not pushed", notPushed, len(results))
+	}
+	return nil
+}
+
+func runExecCommand(args []string) error {
+	flags := flag.NewFlagSet("exec", flag.ContinueOnError)
+	concurrency := flags.Int("j", config.ExecConcurrency, "how many worktrees to run the command in at once")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runExecCommand(). Error: %w", err)
+	}
+	remaining := flags.Args()
+	if len(remaining) < 3 || remaining[1] != "--" {
+		return fmt.Errorf("usage: git-tool exec [-j concurrency] <effort> -- <command> [args...]")
+	}
+
+	theEffort, err := findEffort(remaining[0])
+	if err != nil {
+		return fmt.Errorf("error, when findEffort() for runExecCommand(). Error: %w", err)
+	}
+	results, err := execInEffort(theEffort, remaining[2:], *concurrency, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("error, when execInEffort() for runExecCommand(). Error: %w", err)
+	}
+	fmt.Println()
+	fmt.Println(formatExecResults(strings.Join(remaining[2:], " "), results, 0))
+	failed := 0
+	for _, r := range results {
+		if r.Outcome != repoOutcomeSucceeded {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of %d repos failed", failed, len(results))
+	}
+	return nil
+}
+
+func runSearchCommand(args []string) error {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	ignoreCase := flags.Bool("i", false, "ignore case")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runSearchCommand(). Error: %w", err)
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: git-tool search [-i] <effort> <pattern>")
+	}
+
+	theEffort, err := findEffort(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error, when findEffort() for runSearchCommand(). Error: %w", err)
+	}
+	matches, err := searchEffort(theEffort, flags.Arg(1), *ignoreCase)
+	if err != nil {
+		return fmt.Errorf("error, when searchEffort() for runSearchCommand(). Error: %w", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no matches found")
+	}
+	fmt.Println(formatSearchMatches(matches))
+	return nil
+}
+
+func runDiffCommand(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	patchFile := flags.String("patch", "", "write the combined diff of every repo to this file as a patch")
+	markdownFile := flags.String("markdown", "", "write the combined diff of every repo to this file as markdown")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runDiffCommand(). Error: %w", err)
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: git-tool diff [-patch file] [-markdown file] <effort>")
+	}
+
+	theEffort, err := findEffort(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error, when findEffort() for runDiffCommand(). Error: %w", err)
+	}
+	diffs, err := fetchEffortDiff(theEffort)
+	if err != nil {
+		return fmt.Errorf("error, when fetchEffortDiff() for runDiffCommand(). Error: %w", err)
+	}
+
+	if *patchFile == "" && *markdownFile == "" {
+		for _, d := range diffs {
+			fmt.Println(d.summary())
+			for _, f := range d.Files {
+				fmt.Printf("    %s  +%d -%d\n", f.Path, f.Added, f.Deleted)
+			}
+		}
+		return nil
+	}
+	if *patchFile != "" {
+		err = os.WriteFile(*patchFile, []byte(formatDiffPatch(diffs)), 0644)
+		if err != nil {
+			return fmt.Errorf("error, when writing patch file for runDiffCommand(). Error: %w", err)
+		}
+	}
+	if *markdownFile != "" {
+		err = os.WriteFile(*markdownFile, []byte(formatDiffMarkdown(theEffort, diffs)), 0644)
+		if err != nil {
+			return fmt.Errorf("error, when writing markdown file for runDiffCommand(). Error: %w", err)
+		}
+	}
+	return nil
+}
+
+func runRecoverCommand(args []string) error {
+	flags := flag.NewFlagSet("recover", flag.ContinueOnError)
+	resume := flags.Bool("resume", false, "run the steps the operation didn't complete")
+	rollback := flags.Bool("rollback", false, "undo the steps the operation started")
+	abandon := flags.Bool("abandon", false, "leave everything as it is and stop offering to recover the operation")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runRecoverCommand(). Error: %w", err)
+	}
+	journals, err := fetchUnfinishedOperations()
+	if err != nil {
+		return fmt.Errorf("error, when fetchUnfinishedOperations() for runRecoverCommand(). Error: %w", err)
+	}
+
+	// without an operation id the unfinished operations are listed
+	if flags.NArg() == 0 {
+		if len(journals) == 0 {
+			fmt.Println("no unfinished operations")
+		}
+		for _, j := range journals {
+			fmt.Print(formatJournal(j))
+		}
+		return nil
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: git-tool recover [-resume|-rollback|-abandon <operation id>]")
+	}
+	id, err := strconv.ParseInt(flags.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("operation id must be a number, got %s", flags.Arg(0))
+	}
+	var journal *operationJournal
+	for _, j := range journals {
+		if j.Id == id {
+			journal = j
+		}
+	}
+	if journal == nil {
+		return fmt.Errorf("operation %d is not unfinished, run git-tool recover to list the ones that are", id)
+	}
+
+	var results []repoResult
+	switch {
+	case *resume && !*rollback && !*abandon:
+		results, err = resumeOperation(journal)
+	case *rollback && !*resume && !*abandon:
+		results, err = rollbackOperation(journal)
+	case *abandon && !*resume && !*rollback:
+		err = abandonOperation(journal)
+	default:
+		return fmt.Errorf("exactly one of -resume, -rollback or -abandon must be given")
+	}
+	if len(results) != 0 {
+		fmt.Println(formatRepoResults(journal.Title(), results))
+	}
+	if err != nil {
+		return fmt.Errorf("error, when recovering operation %d for runRecoverCommand(). Error: %w", id, err)
+	}
+	return nil
+}
+
+func runHistoryCommand(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	kind := flags.String("kind", "", "only show event or git entries")
+	failed := flags.Bool("failed", false, "only show what failed")
+	search := flags.String("search", "", "only show entries with this text in the action, repo, command or output")
+	limit := flags.Int("limit", 50, "how many of the latest entries to show, 0 for all of them")
+	asJson := flags.Bool("json", false, "print the entries as JSON, output included")
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("error, when parsing flags for runHistoryCommand(). Error: %w", err)
+	}
+	if *kind != "" && *kind != string(operationLogEvent) && *kind != string(operationLogGit) {
+		return fmt.Errorf("-kind must be %s or %s", operationLogEvent, operationLogGit)
+	}
+
+	entries, err := fetchOperationLog(operationLogFilter{
+		Kind:       operationLogKind(*kind),
+		FailedOnly: *failed,
+		Text:       *search,
+		Limit:      *limit,
+	})
+	if err != nil {
+		return fmt.Errorf("error, when fetchOperationLog() for runHistoryCommand(). Error: %w", err)
+	}
+	if *asJson {
+		err = exportOperationLog(os.Stdout, entries)
+		if err != nil {
+			return fmt.Errorf("error, when exportOperationLog() for runHistoryCommand(). Error: %w", err)
+		}
+		return nil
+	}
+	// oldest first reads like a log
+	for i := len(entries) - 1; i >= 0; i-- {
+		fmt.Println(formatOperationLogEntry(entries[i]))
+	}
+	return nil
+}
+
+func runShowCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: git-tool show <effort>")
+	}
+	theEffort, err := findEffort(args[0])
+	if err != nil {
+		return fmt.Errorf("error, when findEffort() for runShowCommand(). Error: %w", err)
+	}
+	details, err := fetchEffortDetail(theEffort)
+	if err != nil {
+		return fmt.Errorf("error, when fetchEffortDetail() for runShowCommand(). Error: %w", err)
+	}
+	for _, d := range details {
+		theEffort.Repos = append(theEffort.Repos, d.theRepo)
+	}
+	fmt.Println(strings.Join(formatEffortDetail(theEffort), "\n"))
+	for _, d := range details {
+		fmt.Println()
+		fmt.Println(strings.Join(formatEffortRepoDetail(theEffort, d), "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// worktreeChanges is the git status of one worktree of an effort
+type worktreeChanges struct {
+	theRepo  repo
+	Staged   []string
+	Unstaged []string
+	// Selected means the repo will be included in the commit
+	Selected bool
+}
+
+func (w worktreeChanges) hasChanges() bool {
+	return len(w.Staged) != 0 || len(w.Unstaged) != 0
+}
+
+func fetchEffortWorktreeChanges(theEffort effort) ([]worktreeChanges, error) {
+	effortRepos, err := fetchReposForEffort(theEffort.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposForEffort() for fetchEffortWorktreeChanges(). Error: %w", err)
+	}
+	changes := make([]*worktreeChanges, len(effortRepos))
+	errs := make([]error, len(effortRepos))
+	runWorkerPool(len(effortRepos), config.GitConcurrency, func(i int) {
+		changes[i], errs[i] = fetchWorktreeChanges(theEffort, effortRepos[i])
+	})
+	var result []worktreeChanges
+	for i := range effortRepos {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		// a missing worktree has nothing to commit
+		if changes[i] != nil {
+			result = append(result, *changes[i])
+		}
+	}
+	return result, nil
+}
+
+func fetchWorktreeChanges(theEffort effort, r repo) (*worktreeChanges, error) {
+	worktreeDir := getWorktreeDir(theEffort, r)
+	exists, err := checkDirectoryExists(worktreeDir)
+	if err != nil {
+		return nil, fmt.Errorf("error, when checkDirectoryExists() for fetchWorktreeChanges(). Error: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	// not using runGitCommand since it trims the leading space that is part of the porcelain format
+	output, err := runGitCombinedOutput(worktreeDir, "status", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("error, when checking status of %s. Output: %s. Error: %w", r.Title(), output, err)
+	}
+	changes := parsePorcelainStatus(string(output))
+	changes.theRepo = r
+	changes.Selected = changes.hasChanges()
+	return &changes, nil
+}
+
+// parsePorcelainStatus splits git status --porcelain output into staged and unstaged paths, untracked files count as unstaged
+func parsePorcelainStatus(output string) worktreeChanges {
+	var result worktreeChanges
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		index, worktree, path := line[0], line[1], line[3:]
+		if index != ' ' && index != '?' {
+			result.Staged = append(result.Staged, path)
+		}
+		if worktree != ' ' {
+			result.Unstaged = append(result.Unstaged, path)
+		}
+	}
+	return result
+}
+
+// formatCommitMessage prefixes the message with the ticket id unless it already starts with it
+func formatCommitMessage(ticket string, message string) string {
+	message = strings.TrimSpace(message)
+	if ticket == "" || strings.HasPrefix(message, ticket) {
+		return message
+	}
+	return ticket + ": " + message
+}
+
+// commitEffort commits the selected worktrees with one shared message
+func commitEffort(theEffort effort, changes []worktreeChanges, message string, stageAll bool) ([]repoResult, string) {
+	if strings.TrimSpace(message) == "" {
+		return nil, "must provide a commit message"
+	}
+	message = formatCommitMessage(theEffort.BranchName, message)
+
+	var results []repoResult
+	for _, c := range changes {
+		if !c.Selected {
+			continue
+		}
+		result := repoResult{theRepo: c.theRepo}
+		worktreeDir := getWorktreeDir(theEffort, c.theRepo)
+		if stageAll {
+			_, err := runGitCommand(worktreeDir, "add", "--all")
+			if err != nil {
+				result.Outcome = repoOutcomeFailed
+				result.Detail = err.Error()
+				results = append(results, result)
+				continue
+			}
+		}
+		// diff --quiet exits with 1 when there are staged changes
+		_, err := runGitCommand(worktreeDir, "diff", "--cached", "--quiet")
+		if err == nil {
+			result.Outcome = repoOutcomeSkipped
+			result.Detail = "nothing staged"
+			results = append(results, result)
+			continue
+		}
+		_, err = runGitCommand(worktreeDir, "commit", "-m", message)
+		if err != nil {
+			result.Outcome = repoOutcomeFailed
+			result.Detail = err.Error()
+			results = append(results, result)
+			continue
+		}
+		sha, err := runGitCommand(worktreeDir, "rev-parse", "--short", "HEAD")
+		if err != nil {
+			result.Outcome = repoOutcomeFailed
+			result.Detail = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Outcome = repoOutcomeSucceeded
+		result.Detail = "committed " + sha
+		results = append(results, result)
+	}
+	markEffortUpdatedAfter(theEffort, results)
+	return results, ""
+}
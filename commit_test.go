@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parsePorcelainStatus(t *testing.T) {
+	got := parsePorcelainStatus("M  staged.go\n M unstaged.go\nMM both.go\n?? new.go\n")
+	expectedStaged := []string{"staged.go", "both.go"}
+	expectedUnstaged := []string{"unstaged.go", "both.go", "new.go"}
+	if !reflect.DeepEqual(got.Staged, expectedStaged) {
+		t.Errorf("got staged %v, but wanted %v", got.Staged, expectedStaged)
+	}
+	if !reflect.DeepEqual(got.Unstaged, expectedUnstaged) {
+		t.Errorf("got unstaged %v, but wanted %v", got.Unstaged, expectedUnstaged)
+	}
+}
+
+func Test_formatCommitMessage(t *testing.T) {
+	tests := []struct {
+		ticket   string
+		message  string
+		expected string
+	}{
+		{"ABC-123", "fix the thing", "ABC-123: fix the thing"},
+		{"ABC-123", "ABC-123 fix the thing", "ABC-123 fix the thing"},
+		{"", " fix the thing ", "fix the thing"},
+	}
+	for _, test := range tests {
+		got := formatCommitMessage(test.ticket, test.message)
+		if got != test.expected {
+			t.Errorf("got %s, but wanted %s", got, test.expected)
+		}
+	}
+}
@@ -2,11 +2,51 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 )
 
+const (
+	trunkUpdateStrategyRebase = "rebase"
+	trunkUpdateStrategyMerge  = "merge"
+)
+
+// toolConfig is read from config.json in the data directory, any setting left out keeps its default
+type toolConfig struct {
+	// TrunkUpdateStrategy is how an effort branch is brought up to date with trunk, either rebase or merge
+	TrunkUpdateStrategy string `json:"trunkUpdateStrategy"`
+	// PrePushCommand runs in each worktree before it is pushed, the push is skipped if it fails
+	PrePushCommand string `json:"prePushCommand"`
+	// RepoPrePushCommands overrides PrePushCommand for a repo, keyed by repo name
+	RepoPrePushCommands map[string]string `json:"repoPrePushCommands"`
+	// ExecConcurrency is how many worktrees git-tool exec runs the command in at once
+	ExecConcurrency int `json:"execConcurrency"`
+	// GitConcurrency is how many repos git operations such as clone, fetch, apply and delete run against at once
+	GitConcurrency int `json:"gitConcurrency"`
+	// GitTimeoutSeconds is how long a local git command may run before it is killed
+	GitTimeoutSeconds int `json:"gitTimeoutSeconds"`
+	// GitNetworkTimeoutSeconds is how long a git command that talks to the remote, e.g., clone, fetch or push, may run
+	GitNetworkTimeoutSeconds int `json:"gitNetworkTimeoutSeconds"`
+	// PrStateCommand is asked whether the pull request of a branch is merged when git alone can't tell
+	PrStateCommand string `json:"prStateCommand"`
+	// OperationLogRetentionDays is how long the history of actions and git commands is kept
+	OperationLogRetentionDays int `json:"operationLogRetentionDays"`
+	// IdleEffortDays is how long an effort can go without being opened or updated before its status becomes idle
+	IdleEffortDays int `json:"idleEffortDays"`
+}
+
+var config = toolConfig{
+	TrunkUpdateStrategy:       trunkUpdateStrategyRebase,
+	ExecConcurrency:           4,
+	GitConcurrency:            4,
+	GitTimeoutSeconds:         60,
+	GitNetworkTimeoutSeconds:  300,
+	OperationLogRetentionDays: 30,
+	IdleEffortDays:            14,
+}
+
 func init() {
 	if os.Getenv("TEST_MODE") != "true" {
 		homeDir, err := os.UserHomeDir()
@@ -16,16 +56,18 @@ func init() {
 		dataDirectory = fmt.Sprintf("%s/git_tool_data/", homeDir)
 		reposDirectory = dataDirectory + "repos/"
 		effortsDirectory = dataDirectory + "efforts/"
+		backupsDirectory = dataDirectory + "backups/"
+		recoveryDirectory = dataDirectory + "recovery/"
 		err = os.MkdirAll(dataDirectory, os.ModePerm)
 		if err != nil {
 			log.Fatalf("error, could not create data directory. Error: %v", err)
 		}
 
-		dbFile := fmt.Sprintf("%s%s", dataDirectory, "data")
-		_, err = os.Stat(dbFile)
+		databaseFile = fmt.Sprintf("%s%s", dataDirectory, "data")
+		_, err = os.Stat(databaseFile)
 		if os.IsNotExist(err) {
 			var file *os.File
-			file, err = os.Create(dbFile)
+			file, err = os.Create(databaseFile)
 			if err != nil {
 				log.Fatalf("error, when creating db file. Error: %v", err)
 			}
@@ -35,9 +77,58 @@ func init() {
 			log.Fatalf("error, when checking db file exists. Error: %v", err)
 		}
 
-		database, err = sql.Open("sqlite3", dbFile)
+		err = openDatabase()
 		if err != nil {
-			log.Fatalf("error, when establishing connection with sqlite db. Error: %v", err)
+			log.Fatalf("error, when openDatabase() for init(). Error: %v", err)
 		}
+
+		err = loadConfig(dataDirectory + "config.json")
+		if err != nil {
+			log.Fatalf("error, when loadConfig() for init(). Error: %v", err)
+		}
+	}
+}
+
+func loadConfig(configFile string) error {
+	content, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error, when reading config file. Error: %w", err)
+	}
+	err = json.Unmarshal(content, &config)
+	if err != nil {
+		return fmt.Errorf("error, when parsing config file %s. Error: %w", configFile, err)
+	}
+	if config.TrunkUpdateStrategy != trunkUpdateStrategyRebase && config.TrunkUpdateStrategy != trunkUpdateStrategyMerge {
+		return fmt.Errorf("error, trunkUpdateStrategy in %s must be %s or %s", configFile, trunkUpdateStrategyRebase, trunkUpdateStrategyMerge)
+	}
+	if config.ExecConcurrency < 1 {
+		return fmt.Errorf("error, execConcurrency in %s must be at least 1", configFile)
+	}
+	if config.GitConcurrency < 1 {
+		return fmt.Errorf("error, gitConcurrency in %s must be at least 1", configFile)
+	}
+	if config.GitTimeoutSeconds < 1 || config.GitNetworkTimeoutSeconds < 1 {
+		return fmt.Errorf("error, gitTimeoutSeconds and gitNetworkTimeoutSeconds in %s must be at least 1", configFile)
+	}
+	if config.OperationLogRetentionDays < 1 {
+		return fmt.Errorf("error, operationLogRetentionDays in %s must be at least 1", configFile)
+	}
+	if config.IdleEffortDays < 1 {
+		return fmt.Errorf("error, idleEffortDays in %s must be at least 1", configFile)
+	}
+	return nil
+}
+
+func openDatabase() error {
+	var err error
+	// foreign keys are off by default in sqlite and must be enabled for every connection, the busy timeout lets the
+	// worker pool record journal steps at the same time without failing on a locked database
+	database, err = sql.Open("sqlite3", "file:"+databaseFile+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return fmt.Errorf("error, when establishing connection with sqlite db. Error: %w", err)
 	}
+	return nil
 }
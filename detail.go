@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// effortRepoDetail is where a repo of an effort lives on disk and what it has checked out
+type effortRepoDetail struct {
+	theRepo     repo
+	WorktreeDir string
+	// Branch is what the worktree has checked out, empty when the worktree is missing
+	Branch string
+	Trunk  string
+	// Problem is why the branch or trunk couldn't be read
+	Problem string
+}
+
+// fetchEffortDetail reads the worktree of every repo of the effort, only local git commands are run
+func fetchEffortDetail(theEffort effort) ([]effortRepoDetail, error) {
+	effortRepos, err := fetchReposForEffort(theEffort.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposForEffort() for fetchEffortDetail(). Error: %w", err)
+	}
+	result := make([]effortRepoDetail, len(effortRepos))
+	runWorkerPool(len(effortRepos), config.GitConcurrency, func(i int) {
+		result[i] = fetchEffortRepoDetail(theEffort, effortRepos[i])
+	})
+	return result, nil
+}
+
+func fetchEffortRepoDetail(theEffort effort, r repo) effortRepoDetail {
+	detail := effortRepoDetail{
+		theRepo:     r,
+		WorktreeDir: getWorktreeDir(theEffort, r),
+	}
+	trunk, err := getTrunkBranch(r)
+	if err != nil {
+		detail.Problem = err.Error()
+	}
+	detail.Trunk = trunk
+
+	exists, err := checkDirectoryExists(detail.WorktreeDir)
+	if err != nil {
+		detail.Problem = err.Error()
+		return detail
+	}
+	if !exists {
+		return detail
+	}
+	// HEAD comes back when the worktree is detached
+	branch, err := runGitCommand(detail.WorktreeDir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		detail.Problem = err.Error()
+		return detail
+	}
+	detail.Branch = branch
+	return detail
+}
+
+// formatEffortDetail is the read-only overview of an effort, it is shared by the effort detail view and git-tool show
+func formatEffortDetail(theEffort effort) []string {
+	now := time.Now()
+	return []string{
+		fmt.Sprintf("name         %s", theEffort.Name),
+		fmt.Sprintf("description  %s", theEffort.Desc),
+		fmt.Sprintf("branch       %s", theEffort.BranchName),
+		// the ticket id is the branch name, it is what commit messages are prefixed with
+		fmt.Sprintf("ticket       %s", theEffort.BranchName),
+		fmt.Sprintf("status       %s", theEffort.status(now)),
+		fmt.Sprintf("created      %s", formatEffortTimestamp(theEffort.CreatedAt, now)),
+		fmt.Sprintf("updated      %s", formatEffortTimestamp(theEffort.UpdatedAt, now)),
+		fmt.Sprintf("opened       %s", formatEffortTimestamp(theEffort.LastOpenedAt, now)),
+	}
+}
+
+// formatEffortRepoDetail is the lines of one repo, the first is the repo name
+func formatEffortRepoDetail(theEffort effort, d effortRepoDetail) []string {
+	branch := d.Branch
+	if branch == "" {
+		branch = "worktree is missing"
+	} else if branch != theEffort.BranchName {
+		branch += fmt.Sprintf(" (expected %s)", theEffort.BranchName)
+	}
+	lines := []string{
+		d.theRepo.Title(),
+		fmt.Sprintf("  worktree  %s", d.WorktreeDir),
+		fmt.Sprintf("  branch    %s", branch),
+		fmt.Sprintf("  trunk     %s", d.Trunk),
+	}
+	if d.Problem != "" {
+		lines = append(lines, fmt.Sprintf("  problem   %s", strings.ReplaceAll(d.Problem, "\n", " ")))
+	}
+	return lines
+}
+
+func formatEffortTimestamp(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04"), formatAge(t, now))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_formatEffortRepoDetail(t *testing.T) {
+	theEffort := effort{Name: "invoices", BranchName: "ABC-12"}
+	r := repo{Url: "git@example.com:team/billing.git"}
+	tests := []struct {
+		name   string
+		detail effortRepoDetail
+		want   []string
+	}{
+		{
+			name:   "on the effort branch",
+			detail: effortRepoDetail{theRepo: r, WorktreeDir: "/efforts/invoices/billing", Branch: "ABC-12", Trunk: "main"},
+			want:   []string{"billing", "  worktree  /efforts/invoices/billing", "  branch    ABC-12", "  trunk     main"},
+		},
+		{
+			name:   "worktree is missing",
+			detail: effortRepoDetail{theRepo: r, WorktreeDir: "/efforts/invoices/billing", Trunk: "main"},
+			want:   []string{"billing", "  worktree  /efforts/invoices/billing", "  branch    worktree is missing", "  trunk     main"},
+		},
+		{
+			name:   "detached",
+			detail: effortRepoDetail{theRepo: r, WorktreeDir: "/efforts/invoices/billing", Branch: "HEAD", Trunk: "main"},
+			want:   []string{"billing", "  worktree  /efforts/invoices/billing", "  branch    HEAD (expected ABC-12)", "  trunk     main"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatEffortRepoDetail(theEffort, tt.detail)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, but wanted %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// repoDiff is the difference between trunk and the effort branch of one repo
+type repoDiff struct {
+	theRepo  repo
+	Trunk    string
+	Files    []fileDiff
+	Expanded bool
+}
+
+type fileDiff struct {
+	Path    string
+	Added   int
+	Deleted int
+	// Patch paths are prefixed with the repo name so patches from every repo can be combined into one file
+	Patch    string
+	Expanded bool
+}
+
+// diffNode is a selectable row of the diff view, fileIndex is -1 for the repo row
+type diffNode struct {
+	repoIndex int
+	fileIndex int
+}
+
+func (d repoDiff) totals() (added int, deleted int) {
+	for _, f := range d.Files {
+		added += f.Added
+		deleted += f.Deleted
+	}
+	return added, deleted
+}
+
+func (d repoDiff) summary() string {
+	added, deleted := d.totals()
+	return fmt.Sprintf("%s  %d files changed, +%d -%d against %s", d.theRepo.Title(), len(d.Files), added, deleted, d.Trunk)
+}
+
+// fetchEffortDiff diffs the effort branch of every repo against the merge base with the freshly fetched trunk
+func fetchEffortDiff(theEffort effort) ([]repoDiff, error) {
+	effortRepos, err := fetchReposForEffort(theEffort.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposForEffort() for fetchEffortDiff(). Error: %w", err)
+	}
+	result := make([]repoDiff, len(effortRepos))
+	errs := make([]error, len(effortRepos))
+	runWorkerPool(len(effortRepos), config.GitConcurrency, func(i int) {
+		result[i], errs[i] = fetchRepoDiff(theEffort, effortRepos[i])
+	})
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchRepoDiff() for fetchEffortDiff(). Error: %w", err)
+	}
+	return result, nil
+}
+
+func fetchRepoDiff(theEffort effort, r repo) (repoDiff, error) {
+	trunk, err := getTrunkBranch(r)
+	if err != nil {
+		return repoDiff{}, fmt.Errorf("error, when getTrunkBranch() for fetchRepoDiff() of repo: %s. Error: %w", r.Title(), err)
+	}
+	err = fetchTrunk(r, trunk)
+	if err != nil {
+		return repoDiff{}, fmt.Errorf("error, when fetchTrunk() for fetchRepoDiff() of repo: %s. Error: %w", r.Title(), err)
+	}
+	remoteTrunk := "origin/" + trunk
+	patch, err := runGitCommand(
+		getBareRepoDir(r),
+		"diff",
+		"--no-color",
+		fmt.Sprintf("--src-prefix=a/%s/", r.Title()),
+		fmt.Sprintf("--dst-prefix=b/%s/", r.Title()),
+		fmt.Sprintf("%s...%s", remoteTrunk, theEffort.BranchName),
+	)
+	if err != nil {
+		return repoDiff{}, fmt.Errorf("error, when diffing %s for fetchRepoDiff(). Error: %w", r.Title(), err)
+	}
+	return repoDiff{
+		theRepo: r,
+		Trunk:   remoteTrunk,
+		Files:   splitPatchByFile(patch, r.Title()),
+	}, nil
+}
+
+func splitPatchByFile(patch string, repoTitle string) []fileDiff {
+	var result []fileDiff
+	if strings.TrimSpace(patch) == "" {
+		return result
+	}
+	chunks := strings.Split("\n"+patch, "\ndiff --git ")
+	for _, chunk := range chunks[1:] {
+		f := fileDiff{Patch: "diff --git " + chunk + "\n"}
+		lines := strings.Split(chunk, "\n")
+		// the header is "a/<repo>/<path> b/<repo>/<path>", the destination is used so renames show the new name
+		header := lines[0]
+		prefix := " b/" + repoTitle + "/"
+		if i := strings.LastIndex(header, prefix); i >= 0 {
+			f.Path = header[i+len(prefix):]
+		} else {
+			f.Path = header
+		}
+		// only the lines before the first hunk are file headers, inside a hunk "---" is a removed "--" line
+		inHunk := false
+		for _, line := range lines[1:] {
+			if strings.HasPrefix(line, "@@") {
+				inHunk = true
+				continue
+			}
+			if !inHunk {
+				continue
+			}
+			if strings.HasPrefix(line, "+") {
+				f.Added++
+			} else if strings.HasPrefix(line, "-") {
+				f.Deleted++
+			}
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+func getDiffNodes(diffs []repoDiff) []diffNode {
+	var nodes []diffNode
+	for i, d := range diffs {
+		nodes = append(nodes, diffNode{repoIndex: i, fileIndex: -1})
+		if d.Expanded {
+			for j := range d.Files {
+				nodes = append(nodes, diffNode{repoIndex: i, fileIndex: j})
+			}
+		}
+	}
+	return nodes
+}
+
+// formatDiffPatch combines every repo into one patch that applies from the effort directory with git apply
+func formatDiffPatch(diffs []repoDiff) string {
+	var patch strings.Builder
+	for _, d := range diffs {
+		for _, f := range d.Files {
+			patch.WriteString(f.Patch)
+		}
+	}
+	return patch.String()
+}
+
+func formatDiffMarkdown(theEffort effort, diffs []repoDiff) string {
+	var markdown strings.Builder
+	fmt.Fprintf(&markdown, "# %s\n\nBranch: `%s`\n", theEffort.Desc, theEffort.BranchName)
+	for _, d := range diffs {
+		added, deleted := d.totals()
+		fmt.Fprintf(&markdown, "\n## %s\n\n%d files changed, +%d -%d against `%s`\n", d.theRepo.Title(), len(d.Files), added, deleted, d.Trunk)
+		for _, f := range d.Files {
+			fmt.Fprintf(&markdown, "\n### %s\n\n```diff\n%s```\n", f.Path, f.Patch)
+		}
+	}
+	return markdown.String()
+}
+
+var diffAddedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+var diffDeletedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+var diffHunkStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+var diffHeaderStyle = lipgloss.NewStyle().Bold(true)
+
+// diffAddedBackground and diffDeletedBackground mark the changed lines while the code on them is highlighted
+var diffAddedBackground = lipgloss.Color("22")
+var diffDeletedBackground = lipgloss.Color("52")
+
+// syntaxStyle is the chroma style the code of a patch is highlighted with
+var syntaxStyle = styles.Get("monokai")
+
+// colorizePatch colors a patch the way git does, additions green, deletions red and hunk headers cyan. The code in
+// the hunks is highlighted for the language of path, the file extension picks the lexer, with the changed lines
+// keeping a green or red background. Files no lexer matches are colored by their diff markers only.
+func colorizePatch(patch string, path string) string {
+	lines := strings.Split(strings.TrimSuffix(patch, "\n"), "\n")
+	// hunk holds the indexes of the code lines of the current hunk, they are lexed together so constructs spanning
+	// lines, e.g., block comments, are highlighted right
+	var hunk []int
+	flush := func() {
+		code := make([]string, len(hunk))
+		for k, i := range hunk {
+			code[k] = lines[i][1:]
+		}
+		tokens := highlightCode(path, code)
+		for k, i := range hunk {
+			marker := lines[i][:1]
+			markerStyle := lipgloss.NewStyle()
+			codeStyle := lipgloss.NewStyle()
+			switch marker {
+			case "+":
+				markerStyle = diffAddedStyle
+				codeStyle = codeStyle.Background(diffAddedBackground)
+			case "-":
+				markerStyle = diffDeletedStyle
+				codeStyle = codeStyle.Background(diffDeletedBackground)
+			}
+			if tokens == nil {
+				if marker != " " {
+					lines[i] = markerStyle.Render(lines[i])
+				}
+				continue
+			}
+			lines[i] = markerStyle.Render(marker) + renderTokens(tokens[k], codeStyle)
+		}
+		hunk = nil
+	}
+	inHunk := false
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git"):
+			flush()
+			inHunk = false
+			lines[i] = diffHeaderStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			// no line inside a hunk starts with @@, those start with a space, +, - or \
+			flush()
+			inHunk = true
+			lines[i] = diffHunkStyle.Render(line)
+		case !inHunk, strings.HasPrefix(line, "\\"):
+			// file headers and "\ No newline at end of file"
+			lines[i] = diffHeaderStyle.Render(line)
+		case line == "":
+			// an empty context line that lost its leading space, e.g., to an editor
+		default:
+			hunk = append(hunk, i)
+		}
+	}
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// highlightCode splits the tokens of the code into one slice per line, nil means no lexer matches path
+func highlightCode(path string, code []string) [][]chroma.Token {
+	lexer := lexers.Match(filepath.Base(path))
+	if lexer == nil || len(code) == 0 {
+		return nil
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, strings.Join(code, "\n")+"\n")
+	if err != nil {
+		return nil
+	}
+	tokens := chroma.SplitTokensIntoLines(iterator.Tokens())
+	if len(tokens) < len(code) {
+		return nil
+	}
+	return tokens[:len(code)]
+}
+
+func renderTokens(tokens []chroma.Token, base lipgloss.Style) string {
+	var line strings.Builder
+	for _, token := range tokens {
+		value := strings.TrimSuffix(token.Value, "\n")
+		if value == "" {
+			continue
+		}
+		style := base
+		entry := syntaxStyle.Get(token.Type)
+		if entry.Colour.IsSet() {
+			style = style.Foreground(lipgloss.Color(entry.Colour.String()))
+		}
+		if entry.Bold == chroma.Yes {
+			style = style.Bold(true)
+		}
+		line.WriteString(style.Render(value))
+	}
+	return line.String()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func Test_splitPatchByFile(t *testing.T) {
+	patch := `diff --git a/web/main.go b/web/main.go
+index 1111111..2222222 100644
+--- a/web/main.go
++++ b/web/main.go
+@@ -1,2 +1,3 @@
+ package main
+-var a = 1
++var a = 2
++var b = 3
+diff --git a/web/old.txt b/web/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt`
+	got := splitPatchByFile(patch, "web")
+	if len(got) != 2 {
+		t.Fatalf("got %d files, but wanted 2", len(got))
+	}
+	if got[0].Path != "main.go" || got[0].Added != 2 || got[0].Deleted != 1 {
+		t.Errorf("got %s +%d -%d, but wanted main.go +2 -1", got[0].Path, got[0].Added, got[0].Deleted)
+	}
+	if got[1].Path != "new.txt" || got[1].Added != 0 || got[1].Deleted != 0 {
+		t.Errorf("got %s +%d -%d, but wanted new.txt +0 -0", got[1].Path, got[1].Added, got[1].Deleted)
+	}
+}
+
+func Test_splitPatchByFile_markerLikeLines(t *testing.T) {
+	// a removed "-- comment" SQL line and an added "---" YAML document start look like file headers
+	patch := `diff --git a/web/schema.sql b/web/schema.sql
+index 1111111..2222222 100644
+--- a/web/schema.sql
++++ b/web/schema.sql
+@@ -1,2 +1,1 @@
+--- comment
+ CREATE TABLE a (id INTEGER);
+diff --git a/web/config.yaml b/web/config.yaml
+index 3333333..4444444 100644
+--- a/web/config.yaml
++++ b/web/config.yaml
+@@ -1 +1,3 @@
++---
++++counter: 1
+ name: web`
+	got := splitPatchByFile(patch, "web")
+	if len(got) != 2 {
+		t.Fatalf("got %d files, but wanted 2", len(got))
+	}
+	if got[0].Path != "schema.sql" || got[0].Added != 0 || got[0].Deleted != 1 {
+		t.Errorf("got %s +%d -%d, but wanted schema.sql +0 -1", got[0].Path, got[0].Added, got[0].Deleted)
+	}
+	if got[1].Path != "config.yaml" || got[1].Added != 2 || got[1].Deleted != 0 {
+		t.Errorf("got %s +%d -%d, but wanted config.yaml +2 -0", got[1].Path, got[1].Added, got[1].Deleted)
+	}
+}
+
+func Test_highlightCode(t *testing.T) {
+	// the block comment spans lines so the lines have to be lexed together
+	code := []string{"func main() {", "\t/* start", "\tend */", "}"}
+	got := highlightCode("web/main.go", code)
+	if len(got) != len(code) {
+		t.Fatalf("got %d lines of tokens, but wanted %d", len(got), len(code))
+	}
+	if got[0][0].Type != chroma.KeywordDeclaration {
+		t.Errorf("got %s for func, but wanted a declaration keyword", got[0][0].Type)
+	}
+	for _, token := range got[2] {
+		if strings.TrimSpace(token.Value) != "" && token.Type != chroma.CommentMultiline {
+			t.Errorf("got %s for %q, but wanted the end of the block comment", token.Type, token.Value)
+		}
+	}
+	if highlightCode("web/notes.unknown-extension", code) != nil {
+		t.Error("got tokens for a file no lexer matches, but wanted none")
+	}
+}
+
+func Test_colorizePatch_keepsText(t *testing.T) {
+	patch := `diff --git a/web/main.go b/web/main.go
+--- a/web/main.go
++++ b/web/main.go
+@@ -1,2 +1,2 @@
+ package main
+-var a = 1
++var a = 2
+\ No newline at end of file`
+	got := ansi.Strip(colorizePatch(patch+"\n", "main.go"))
+	if got != patch {
+		t.Errorf("got %q, but wanted the patch text unchanged under the colors", got)
+	}
+}
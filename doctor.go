@@ -0,0 +1,367 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// doctorIssue is a mismatch between the database, the filesystem and git along with how to fix it
+type doctorIssue struct {
+	Problem string
+	// FixDescription explains what fix will do, it doubles as the dry run output
+	FixDescription string
+	fix            func() error
+}
+
+type effortRepoPair struct {
+	theEffort effort
+	theRepo   repo
+}
+
+// detectDoctorIssues looks for orphans in every direction, database rows without files on disk and files on disk
+// without database rows
+func detectDoctorIssues() ([]doctorIssue, error) {
+	var issues []doctorIssue
+
+	danglingRows, err := detectDanglingEffortRepoRows()
+	if err != nil {
+		return nil, fmt.Errorf("error, when detectDanglingEffortRepoRows() for detectDoctorIssues(). Error: %w", err)
+	}
+	issues = append(issues, danglingRows...)
+
+	repoItems, err := fetchRepos()
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchRepos() for detectDoctorIssues(). Error: %w", err)
+	}
+	effortItems, err := fetchEfforts()
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchEfforts() for detectDoctorIssues(). Error: %w", err)
+	}
+	pairs, err := fetchEffortRepoPairs()
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchEffortRepoPairs() for detectDoctorIssues(). Error: %w", err)
+	}
+
+	effortBranches := make(map[string]bool)
+	for _, item := range effortItems {
+		effortBranches[item.(effort).BranchName] = true
+	}
+	reposByTitle := make(map[string]repo)
+	reposByDirName := make(map[string]repo)
+	for _, item := range repoItems {
+		r := item.(repo)
+		reposByTitle[r.Title()] = r
+		reposByDirName[r.getRepoDirectoryName()] = r
+
+		exists, err := checkDirectoryExists(getBareRepoDir(r))
+		if err != nil {
+			return nil, fmt.Errorf("error, when checkDirectoryExists() for detectDoctorIssues(). Error: %w", err)
+		}
+		if !exists {
+			issues = append(issues, doctorIssue{
+				Problem:        fmt.Sprintf("repo %s is registered but has not been cloned", r.Title()),
+				FixDescription: fmt.Sprintf("clone %s", r.Url),
+				fix: func() error {
+					return cloneRepo(r.Url)
+				},
+			})
+			continue
+		}
+
+		stale, err := runGitCommand(getBareRepoDir(r), "worktree", "prune", "--dry-run", "--verbose")
+		if err != nil {
+			return nil, fmt.Errorf("error, when checking for stale worktrees for detectDoctorIssues(). Error: %w", err)
+		}
+		if stale != "" {
+			issues = append(issues, doctorIssue{
+				Problem:        fmt.Sprintf("repo %s has stale worktree records: %s", r.Title(), stale),
+				FixDescription: fmt.Sprintf("run git worktree prune in %s", getBareRepoDir(r)),
+				fix: func() error {
+					_, err := runGitCommand(getBareRepoDir(r), "worktree", "prune")
+					return err
+				},
+			})
+		}
+
+		preserved, err := detectOrphanedPreservedWork(r, effortBranches)
+		if err != nil {
+			return nil, fmt.Errorf("error, when detectOrphanedPreservedWork() for detectDoctorIssues(). Error: %w", err)
+		}
+		issues = append(issues, preserved...)
+	}
+
+	repoDirEntries, err := os.ReadDir(reposDirectory)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error, when reading repos directory for detectDoctorIssues(). Error: %w", err)
+	}
+	for _, entry := range repoDirEntries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, ok := reposByDirName[entry.Name()]; ok {
+			continue
+		}
+		bareRepoDir := reposDirectory + entry.Name()
+		issues = append(issues, doctorIssue{
+			Problem:        fmt.Sprintf("clone %s exists on disk but is not registered", bareRepoDir),
+			FixDescription: "register the repo using its origin url",
+			fix: func() error {
+				url, err := runGitCommand(bareRepoDir, "config", "--get", "remote.origin.url")
+				if err != nil {
+					return fmt.Errorf("error, when reading origin url. Error: %w", err)
+				}
+				_, err = database.Exec(
+					`INSERT OR IGNORE INTO repo (url)
+					VALUES (?)`,
+					url,
+				)
+				return err
+			},
+		})
+	}
+
+	effortsByName := make(map[string]effort)
+	for _, item := range effortItems {
+		e := item.(effort)
+		effortsByName[e.Name] = e
+		effortDir := effortsDirectory + e.Name
+		exists, err := checkDirectoryExists(effortDir)
+		if err != nil {
+			return nil, fmt.Errorf("error, when checkDirectoryExists() for detectDoctorIssues(). Error: %w", err)
+		}
+		if !exists {
+			issues = append(issues, doctorIssue{
+				Problem:        fmt.Sprintf("effort %s has no directory", e.Name),
+				FixDescription: fmt.Sprintf("create %s", effortDir),
+				fix: func() error {
+					return os.MkdirAll(effortDir, 0755)
+				},
+			})
+		}
+	}
+
+	registeredWorktrees := make(map[string]bool)
+	for _, pair := range pairs {
+		worktreeDir := getWorktreeDir(pair.theEffort, pair.theRepo)
+		registeredWorktrees[worktreeDir] = true
+		exists, err := checkDirectoryExists(worktreeDir)
+		if err != nil {
+			return nil, fmt.Errorf("error, when checkDirectoryExists() for detectDoctorIssues(). Error: %w", err)
+		}
+		if !exists {
+			issues = append(issues, doctorIssue{
+				Problem:        fmt.Sprintf("effort %s includes repo %s but its worktree is missing", pair.theEffort.Name, pair.theRepo.Title()),
+				FixDescription: fmt.Sprintf("recreate the worktree at %s", worktreeDir),
+				fix: func() error {
+					return createWorktree(pair.theEffort, pair.theRepo)
+				},
+			})
+		}
+	}
+
+	effortDirEntries, err := os.ReadDir(effortsDirectory)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error, when reading efforts directory for detectDoctorIssues(). Error: %w", err)
+	}
+	for _, effortEntry := range effortDirEntries {
+		if !effortEntry.IsDir() {
+			continue
+		}
+		effortDir := effortsDirectory + effortEntry.Name()
+		worktreeEntries, err := os.ReadDir(effortDir)
+		if err != nil {
+			return nil, fmt.Errorf("error, when reading effort directory for detectDoctorIssues(). Error: %w", err)
+		}
+		var orphanedWorktrees []string
+		for _, worktreeEntry := range worktreeEntries {
+			worktreeDir := effortDir + "/" + worktreeEntry.Name()
+			if worktreeEntry.IsDir() && !registeredWorktrees[worktreeDir] {
+				orphanedWorktrees = append(orphanedWorktrees, worktreeDir)
+			}
+		}
+
+		_, effortExists := effortsByName[effortEntry.Name()]
+		for _, worktreeDir := range orphanedWorktrees {
+			theRepo, repoExists := reposByTitle[worktreeDir[strings.LastIndex(worktreeDir, "/")+1:]]
+			issue := doctorIssue{
+				Problem: fmt.Sprintf("worktree %s is not tracked by any effort", worktreeDir),
+			}
+			if repoExists {
+				issue.FixDescription = "remove the worktree, git refuses if it has uncommitted changes"
+				issue.fix = func() error {
+					_, err := runGitCommand(getBareRepoDir(theRepo), "worktree", "remove", worktreeDir)
+					return err
+				}
+			} else {
+				issue.FixDescription = "none, the repo it belongs to is not registered"
+			}
+			issues = append(issues, issue)
+		}
+		// the directory can only be removed once its worktrees are, so it is left for the pass after they have been fixed
+		if !effortExists && len(orphanedWorktrees) == 0 {
+			issues = append(issues, doctorIssue{
+				Problem:        fmt.Sprintf("effort directory %s has no effort", effortDir),
+				FixDescription: "remove the directory once it is empty",
+				fix: func() error {
+					return os.Remove(effortDir)
+				},
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// detectOrphanedPreservedWork finds the uncommitted work preserveUncommittedWork kept in git for branches no effort
+// has anymore, nothing in the tool restores it so it is only listed
+func detectOrphanedPreservedWork(r repo, effortBranches map[string]bool) ([]doctorIssue, error) {
+	var issues []doctorIssue
+	stashRefs, err := runGitCommand(getBareRepoDir(r), "for-each-ref", "--format=%(refname)", getUncommittedWorkRef(""))
+	if err != nil {
+		return nil, fmt.Errorf("error, when listing stash refs for detectOrphanedPreservedWork(). Error: %w", err)
+	}
+	for _, ref := range strings.Fields(stashRefs) {
+		branchName := strings.TrimPrefix(ref, getUncommittedWorkRef(""))
+		if effortBranches[branchName] {
+			continue
+		}
+		issues = append(issues, doctorIssue{
+			Problem:        fmt.Sprintf("repo %s has stashed uncommitted work of branch %s that no effort tracks: %s", r.Title(), branchName, ref),
+			FixDescription: fmt.Sprintf("none, apply it with git stash apply %s or drop it with git update-ref -d %s", ref, ref),
+		})
+	}
+
+	tips, err := runGitCommand(getBareRepoDir(r), "for-each-ref", "--format=%(refname:short)%09%(contents:subject)", "refs/heads")
+	if err != nil {
+		return nil, fmt.Errorf("error, when listing branch tips for detectOrphanedPreservedWork(). Error: %w", err)
+	}
+	for _, line := range strings.Split(tips, "\n") {
+		branchName, subject, _ := strings.Cut(line, "\t")
+		if subject != wipCommitMessage || effortBranches[branchName] {
+			continue
+		}
+		issues = append(issues, doctorIssue{
+			Problem:        fmt.Sprintf("repo %s has uncommitted work saved as a WIP commit on branch %s that no effort tracks", r.Title(), branchName),
+			FixDescription: fmt.Sprintf("none, adopt the branch or undo the commit with git reset HEAD~1 in a worktree of %s", branchName),
+		})
+	}
+	return issues, nil
+}
+
+func detectDanglingEffortRepoRows() ([]doctorIssue, error) {
+	rows, err := database.Query(
+		`SELECT er.effort_id, er.repo_id
+		FROM effort_repo er
+		LEFT JOIN effort e ON e.id = er.effort_id
+		LEFT JOIN repo r ON r.id = er.repo_id
+		WHERE e.id IS NULL OR r.id IS NULL`,
+	)
+
+	defer func(rows *sql.Rows) {
+		if rows != nil {
+			closeRowsError := rows.Close()
+			if closeRowsError != nil {
+				// no choice but to log the error since defer doesn't let us return errors
+				// defer is needed though because it ensures a cleanup attempt is made even if we should return early due to an error
+				log.Printf("error, when attempting to close database rows: %v", closeRowsError)
+			}
+		}
+	}(rows)
+
+	if err != nil {
+		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %w", err)
+	}
+
+	var issues []doctorIssue
+	for rows.Next() {
+		var effortId, repoId int64
+		err = rows.Scan(
+			&effortId,
+			&repoId,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error, when scanning database rows. Error: %w", err)
+		}
+		issues = append(issues, doctorIssue{
+			Problem:        fmt.Sprintf("effort_repo row (effort %d, repo %d) points at a missing effort or repo", effortId, repoId),
+			FixDescription: "delete the row",
+			fix: func() error {
+				_, err := database.Exec(
+					`DELETE FROM effort_repo
+					WHERE effort_id = ? AND repo_id = ?`,
+					effortId,
+					repoId,
+				)
+				return err
+			},
+		})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error, when iterating through database rows. Error: %w", err)
+	}
+	return issues, nil
+}
+
+func fetchEffortRepoPairs() ([]effortRepoPair, error) {
+	rows, err := database.Query(
+		`SELECT e.id, e.name, e.branch_name, e.description, r.id, r.url, COALESCE(r.trunk_branch, '')
+		FROM effort_repo er
+		JOIN effort e ON e.id = er.effort_id
+		JOIN repo r ON r.id = er.repo_id`,
+	)
+
+	defer func(rows *sql.Rows) {
+		if rows != nil {
+			closeRowsError := rows.Close()
+			if closeRowsError != nil {
+				// no choice but to log the error since defer doesn't let us return errors
+				// defer is needed though because it ensures a cleanup attempt is made even if we should return early due to an error
+				log.Printf("error, when attempting to close database rows: %v", closeRowsError)
+			}
+		}
+	}(rows)
+
+	if err != nil {
+		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %w", err)
+	}
+
+	var result []effortRepoPair
+	for rows.Next() {
+		var p effortRepoPair
+		err = rows.Scan(
+			&p.theEffort.Id,
+			&p.theEffort.Name,
+			&p.theEffort.BranchName,
+			&p.theEffort.Desc,
+			&p.theRepo.Id,
+			&p.theRepo.Url,
+			&p.theRepo.TrunkBranch,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error, when scanning database rows. Error: %w", err)
+		}
+		result = append(result, p)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error, when iterating through database rows. Error: %w", err)
+	}
+	return result, nil
+}
+
+func fixDoctorIssue(issue doctorIssue) error {
+	if issue.fix == nil {
+		return fmt.Errorf("there is no automatic fix for: %s", issue.Problem)
+	}
+	err := issue.fix()
+	if err != nil {
+		return fmt.Errorf("error, when fixing issue: %s. Error: %w", issue.Problem, err)
+	}
+	return nil
+}
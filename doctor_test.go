@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setUpDoctor points the data directories at a temp directory with a throwaway database
+func setUpDoctor(t *testing.T) {
+	t.Helper()
+	openTestDatabase(t)
+	root := t.TempDir()
+	previousReposDirectory := reposDirectory
+	previousEffortsDirectory := effortsDirectory
+	reposDirectory = root + "/repos/"
+	effortsDirectory = root + "/efforts/"
+	t.Cleanup(func() {
+		reposDirectory = previousReposDirectory
+		effortsDirectory = previousEffortsDirectory
+	})
+}
+
+func Test_runDoctorCommand_backsUpBeforeFixing(t *testing.T) {
+	setUpDoctor(t)
+	err := os.MkdirAll(effortsDirectory+"gone", 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = runDoctorCommand([]string{"-fix", "-dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, err := filepath.Glob(backupsDirectory + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 0 {
+		t.Fatalf("got backups %v from a dry run, but wanted none", backups)
+	}
+
+	err = runDoctorCommand([]string{"-fix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, err = filepath.Glob(backupsDirectory + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Errorf("got backups %v, but wanted one taken before fixing", backups)
+	}
+	exists, err := checkDirectoryExists(effortsDirectory + "gone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("got the effort directory without an effort still there, but wanted it fixed")
+	}
+}
+
+func Test_detectDoctorIssues(t *testing.T) {
+	setUpDoctor(t)
+	mkdir := func(dir string) {
+		t.Helper()
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	exec := func(query string, args ...any) {
+		t.Helper()
+		_, err := database.Exec(query, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// alpha is registered but never cloned, beta is cloned but never registered
+	exec(`INSERT INTO repo (id, url) VALUES (1, 'git@example.com:team/alpha.git')`)
+	mkdir(reposDirectory + "beta.git")
+	// billing has no directory and its alpha worktree is missing, search has a directory
+	exec(`INSERT INTO effort (id, name, branch_name, description) VALUES (1, 'billing', 'ABC-1', ''), (2, 'search', 'ABC-2', '')`)
+	exec(`INSERT INTO effort_repo (effort_id, repo_id) VALUES (1, 1)`)
+	// a row left behind by an older version, the foreign keys are switched off on one connection to write it
+	conn, err := database.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []string{
+		`PRAGMA foreign_keys = OFF`,
+		`INSERT INTO effort_repo (effort_id, repo_id) VALUES (3, 1)`,
+		`PRAGMA foreign_keys = ON`,
+	} {
+		_, err = conn.ExecContext(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn.Close()
+	mkdir(effortsDirectory + "search")
+	// an alpha worktree search doesn't include, and a deleted effort's directory that still holds one
+	mkdir(effortsDirectory + "search/alpha")
+	mkdir(effortsDirectory + "deleted/alpha")
+	// a deleted effort's directory that is empty
+	mkdir(effortsDirectory + "empty")
+
+	issues, err := detectDoctorIssues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Problem)
+	}
+	expected := []string{
+		"effort_repo row (effort 3, repo 1) points at a missing effort or repo",
+		"repo alpha is registered but has not been cloned",
+		"clone " + reposDirectory + "beta.git exists on disk but is not registered",
+		"effort billing has no directory",
+		"effort billing includes repo alpha but its worktree is missing",
+		"worktree " + effortsDirectory + "deleted/alpha is not tracked by any effort",
+		"effort directory " + effortsDirectory + "empty has no effort",
+		"worktree " + effortsDirectory + "search/alpha is not tracked by any effort",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got issues\n%s\nbut wanted\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
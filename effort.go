@@ -5,29 +5,44 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 )
 
 type effort struct {
-	Id         int64
-	Name       string
-	BranchName string
-	Desc       string
-	Repos      []repo
+	Id           int64
+	Name         string
+	BranchName   string
+	Desc         string
+	Repos        []repo
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	LastOpenedAt time.Time
 }
 
 func (e effort) Title() string {
 	return e.Name
 }
-func (e effort) Description() string { return e.Desc }
-func (e effort) FilterValue() string { return e.Desc }
 
-func addEffort(effortName, branchName string) (string, error) {
+// Description is what effortDelegate renders under the title, without the highlighting
+func (e effort) Description() string {
+	return e.searchFields(time.Now()).descriptionLine(nil).String()
+}
+
+// FilterValue carries every field the efforts list query is evaluated against, see filterEfforts
+func (e effort) FilterValue() string { return e.searchFields(time.Now()).encode() }
+
+func addEffort(effortName, branchName string) (validationMsg string, err error) {
+	start := time.Now()
 	effortName = strings.TrimSpace(effortName)
+	defer func() {
+		if validationMsg == "" {
+			logEvent("create effort", effortName, start, err)
+		}
+	}()
 	if effortName == "" {
 		return "must provide a name", nil
 	}
@@ -41,8 +56,28 @@ func addEffort(effortName, branchName string) (string, error) {
 		branchName = name
 	}
 
+	// callers look the new effort up by its branch, so one that is taken has to be reported rather than skipped
+	var existingName, existingBranchName string
+	err = database.QueryRow(
+		`SELECT name, branch_name
+		FROM effort
+		WHERE name = ? OR branch_name = ?`,
+		name,
+		branchName,
+	).Scan(&existingName, &existingBranchName)
+	if err == nil {
+		if existingName == name {
+			return fmt.Sprintf("an effort named %s already exists", name), nil
+		}
+		return fmt.Sprintf("effort %s already uses the branch %s", existingName, branchName), nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("error, when checking for an existing effort for addEffort(). Error: %w", err)
+	}
+
 	var wg sync.WaitGroup
-	errChan := make(chan error, 1)
+	// sized for every goroutine so a second failure can never block
+	errChan := make(chan error, 2)
 
 	wg.Add(1)
 	go func() {
@@ -50,12 +85,12 @@ func addEffort(effortName, branchName string) (string, error) {
 		var e error
 		effortDir, e := getEffortDir(name)
 		if e != nil {
-			errChan <- fmt.Errorf("error, when getting effort directory. Error: %v", e)
+			errChan <- fmt.Errorf("error, when getting effort directory. Error: %w", e)
 			return
 		}
 		e = os.MkdirAll(effortDir, 0755)
 		if e != nil {
-			errChan <- fmt.Errorf("error, when creating effort directory. Error: %v", e)
+			errChan <- fmt.Errorf("error, when creating effort directory. Error: %w", e)
 			return
 		}
 	}()
@@ -64,15 +99,18 @@ func addEffort(effortName, branchName string) (string, error) {
 	go func() {
 		defer wg.Done()
 		var e error
+		now := time.Now().UTC().Format(time.RFC3339)
 		_, e = database.Exec(
-			`INSERT OR IGNORE INTO effort (name, branch_name, description)
-			VALUES (?, ?, ?)`,
+			`INSERT INTO effort (name, branch_name, description, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)`,
 			name,
 			branchName,
 			description,
+			now,
+			now,
 		)
 		if e != nil {
-			errChan <- fmt.Errorf("error, when executing sql statement to add effort. Error: %v", e)
+			errChan <- fmt.Errorf("error, when executing sql statement to add effort. Error: %w", e)
 			return
 		}
 	}()
@@ -83,7 +121,7 @@ func addEffort(effortName, branchName string) (string, error) {
 	}()
 
 	if errChanError := <-errChan; errChanError != nil {
-		return "", fmt.Errorf("error, when attempting to fetch data. Error: %v", errChanError)
+		return "", fmt.Errorf("error, when attempting to fetch data. Error: %w", errChanError)
 	}
 
 	return "", nil
@@ -91,8 +129,10 @@ func addEffort(effortName, branchName string) (string, error) {
 
 func fetchEfforts() ([]list.Item, error) {
 	rows, err := database.Query(
-		`SELECT id, name, branch_name, description
-		FROM effort e`,
+		`SELECT id, name, branch_name, description, COALESCE(created_at, ''), COALESCE(updated_at, ''),
+			COALESCE(last_opened_at, '')
+		FROM effort e
+		ORDER BY name`,
 	)
 
 	defer func(rows *sql.Rows) {
@@ -107,31 +147,46 @@ func fetchEfforts() ([]list.Item, error) {
 	}(rows)
 
 	if err != nil {
-		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %v", err)
+		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %w", err)
 	}
 
 	var result []effort
 	for rows.Next() {
 		var r effort
+		var createdAt, updatedAt, lastOpenedAt string
 		err = rows.Scan(
 			&r.Id,
 			&r.Name,
 			&r.BranchName,
 			&r.Desc,
+			&createdAt,
+			&updatedAt,
+			&lastOpenedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error, when scanning database rows. Error: %v", err)
+			return nil, fmt.Errorf("error, when scanning database rows. Error: %w", err)
 		}
+		r.CreatedAt = parseEffortTimestamp(createdAt)
+		r.UpdatedAt = parseEffortTimestamp(updatedAt)
+		r.LastOpenedAt = parseEffortTimestamp(lastOpenedAt)
 		result = append(result, r)
 	}
 
 	err = rows.Err()
 	if err != nil {
-		return nil, fmt.Errorf("error, when iterating through database rows. Error: %v", err)
+		return nil, fmt.Errorf("error, when iterating through database rows. Error: %w", err)
 	}
 
+	// the repos are what the status is derived from and what the repo: query of the efforts list matches
+	effortRepoUrls, err := fetchEffortRepoUrls()
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchEffortRepoUrls() for fetchEfforts(). Error: %w", err)
+	}
 	efforts := make([]list.Item, len(result))
 	for i, r := range result {
+		for _, url := range effortRepoUrls[r.Id] {
+			r.Repos = append(r.Repos, repo{Url: url})
+		}
 		efforts[i] = r
 	}
 	if len(efforts) == 0 {
@@ -141,7 +196,64 @@ func fetchEfforts() ([]list.Item, error) {
 	return efforts, nil
 }
 
-func applyRepoSelectionForEffort(theEffort effort, repos []list.Item) (string, error) {
+func fetchEffortByBranchName(branchName string) (effort, error) {
+	var e effort
+	err := database.QueryRow(
+		`SELECT id, name, branch_name, description
+		FROM effort
+		WHERE branch_name = ?`,
+		branchName,
+	).Scan(
+		&e.Id,
+		&e.Name,
+		&e.BranchName,
+		&e.Desc,
+	)
+	if err != nil {
+		return effort{}, fmt.Errorf("error, when looking up effort with branch %s. Error: %w", branchName, err)
+	}
+	return e, nil
+}
+
+// findEffort is used by the command line so efforts can be referenced by either their name or branch
+func findEffort(nameOrBranch string) (effort, error) {
+	var e effort
+	var createdAt, updatedAt, lastOpenedAt string
+	err := database.QueryRow(
+		`SELECT id, name, branch_name, description, COALESCE(created_at, ''), COALESCE(updated_at, ''),
+			COALESCE(last_opened_at, '')
+		FROM effort
+		WHERE name = ? OR branch_name = ?`,
+		nameOrBranch,
+		nameOrBranch,
+	).Scan(
+		&e.Id,
+		&e.Name,
+		&e.BranchName,
+		&e.Desc,
+		&createdAt,
+		&updatedAt,
+		&lastOpenedAt,
+	)
+	if err == sql.ErrNoRows {
+		return effort{}, fmt.Errorf("there is no effort named %s", nameOrBranch)
+	}
+	if err != nil {
+		return effort{}, fmt.Errorf("error, when looking up effort %s. Error: %w", nameOrBranch, err)
+	}
+	e.CreatedAt = parseEffortTimestamp(createdAt)
+	e.UpdatedAt = parseEffortTimestamp(updatedAt)
+	e.LastOpenedAt = parseEffortTimestamp(lastOpenedAt)
+	return e, nil
+}
+
+func applyRepoSelectionForEffort(theEffort effort, repos []list.Item) (validationMsg string, err error) {
+	start := time.Now()
+	defer func() {
+		if validationMsg == "" {
+			logEvent("apply repo selection", theEffort.Name, start, err)
+		}
+	}()
 	var selected []repo
 	var notSelected []repo
 	for _, r := range repos {
@@ -156,356 +268,372 @@ func applyRepoSelectionForEffort(theEffort effort, repos []list.Item) (string, e
 		return "must select at least one repo", nil
 	}
 
-	var wg sync.WaitGroup
-	errChan := make(chan error, 1)
-
-	err := os.MkdirAll(effortsDirectory, os.ModePerm)
+	err = os.MkdirAll(effortsDirectory, os.ModePerm)
 	if err != nil {
-		return "", fmt.Errorf("error, when creating effort directory for applyRepoSelectionForEffort(). Error: %v", err)
+		return "", fmt.Errorf("error, when creating effort directory for applyRepoSelectionForEffort(). Error: %w", err)
 	}
 
-	for _, theRepo := range selected {
-		wg.Add(1)
-		go func(r repo) {
-			defer wg.Done()
-			e := createWorktree(theEffort, r)
-			if e != nil {
-				errChan <- fmt.Errorf("error, when createWorktree() for applyRepoSelectionForEffort() of key: %s. Error: %v", r.Title(), e)
-				return
-			}
-		}(theRepo)
+	steps, err := planRepoSelectionSteps(theEffort, selected, notSelected)
+	if err != nil {
+		return "", fmt.Errorf("error, when planRepoSelectionSteps() for applyRepoSelectionForEffort(). Error: %w", err)
 	}
-
-	for _, theRepo := range notSelected {
-		wg.Add(1)
-		go func(r repo) {
-			defer wg.Done()
-			e := deleteWorktree(theEffort, r)
-			if e != nil {
-				errChan <- fmt.Errorf("error, when deleteWorktree() for applyRepoSelectionForEffort() of key: %s. Error: %v", r.Title(), e)
-				return
-			}
-		}(theRepo)
+	journal, err := beginJournal(operationKindApply, theEffort, false, "", steps)
+	if err != nil {
+		return "", fmt.Errorf("error, when beginJournal() for applyRepoSelectionForEffort(). Error: %w", err)
 	}
-
-	go func() {
-		wg.Wait()
-		close(errChan)
-	}()
-
-	if errChanError := <-errChan; errChanError != nil {
-		return "", fmt.Errorf("error, when attempting perform async actions. Error: %v", errChanError)
+	_, err = journal.finishAfter(runJournalSteps(journal))
+	if err != nil {
+		return "", fmt.Errorf("error, when runJournalSteps() for applyRepoSelectionForEffort(). Error: %w", err)
 	}
+	return "", nil
+}
 
-	err = persistRepoSelection(theEffort.Id, selected)
+// planRepoSelectionSteps only has steps for the repos whose worktree changes, every other repo is left alone. A newly
+// selected repo gets its worktree as does a repo that stayed selected but lost its worktree, a repo that is no longer
+// selected loses its worktree.
+func planRepoSelectionSteps(theEffort effort, selected []repo, notSelected []repo) ([]journalStep, error) {
+	previouslySelected, err := fetchSelectedReposForEffort(theEffort.Id)
 	if err != nil {
-		return "", fmt.Errorf("error, when persistRepoSelection() for applyRepoSelectionForEffort(). Error: %v", err)
+		return nil, fmt.Errorf("error, when fetchSelectedReposForEffort() for planRepoSelectionSteps(). Error: %w", err)
 	}
-	return "", nil
+	var steps []journalStep
+	for _, r := range selected {
+		if previouslySelected[r.Id] {
+			exists, err := checkDirectoryExists(getWorktreeDir(theEffort, r))
+			if err != nil {
+				return nil, fmt.Errorf("error, when checkDirectoryExists() for planRepoSelectionSteps(). Error: %w", err)
+			}
+			if exists {
+				continue
+			}
+		}
+		steps = append(steps, journalStep{Action: journalActionCreateWorktree, theRepo: r})
+	}
+	for _, r := range notSelected {
+		if previouslySelected[r.Id] {
+			steps = append(steps, journalStep{Action: journalActionDeleteWorktree, theRepo: r})
+		}
+	}
+	steps = append(steps, journalStep{Action: journalActionPersistSelection})
+	return steps, nil
 }
 
+// createWorktree adds the worktree for the effort branch. Trunk is only ever fetched, the effort branch is created from
+// the freshly fetched remote trunk and the worktree never leaves the effort branch.
 func createWorktree(theEffort effort, r repo) error {
 	worktreeDir := getWorktreeDir(theEffort, r)
+	commandDir := getBareRepoDir(r)
 	alreadyExists, err := checkDirectoryExists(worktreeDir)
 	if err != nil {
-		return fmt.Errorf("error, when checkDirectoryExists() for createWorktree(). Error: %v", err)
+		return fmt.Errorf("error, when checkDirectoryExists() for createWorktree(). Error: %w", err)
+	}
+	remoteBranchExists, err := doesRemoteBranchExist(theEffort.BranchName, commandDir)
+	if err != nil {
+		return fmt.Errorf("error, when doesRemoteBranchExist() for createWorktree(). Error: %w", err)
 	}
 	if !alreadyExists {
-		command := "git"
-		commandDir := reposDirectory + r.getRepoDirectoryName()
-		commmandParts := []string{"worktree", "add", worktreeDir}
 		branchAlreadyExists, err := doesBranchExist(theEffort.BranchName, commandDir)
 		if err != nil {
-			return fmt.Errorf("error, when doesBranchExist() for createWorktree(). Error: %v", err)
+			return fmt.Errorf("error, when doesBranchExist() for createWorktree(). Error: %w", err)
 		}
-		if !branchAlreadyExists {
-			commmandParts = append(commmandParts, "-b")
+		commandParts := []string{"worktree", "add"}
+		if branchAlreadyExists {
+			commandParts = append(commandParts, worktreeDir, theEffort.BranchName)
+		} else {
+			startPoint, err := fetchEffortBranchStartPoint(theEffort, r, remoteBranchExists)
+			if err != nil {
+				return fmt.Errorf("error, when fetchEffortBranchStartPoint() for createWorktree(). Error: %w", err)
+			}
+			// --no-track so the effort branch never tracks trunk
+			commandParts = append(commandParts, "--no-track", "-b", theEffort.BranchName, worktreeDir, startPoint)
+		}
+		_, err = runGitCommand(commandDir, commandParts...)
+		if err != nil {
+			return fmt.Errorf("error, when creating worktree for createWorktree(). Error: %w", err)
 		}
-		commmandParts = append(commmandParts, theEffort.BranchName)
-		cmd := exec.Command(command, commmandParts...)
-		cmd.Dir = commandDir
-		output, err := cmd.CombinedOutput()
+		err = restoreUncommittedWork(worktreeDir, theEffort, r)
 		if err != nil {
-			commandString := command + " " + strings.Join(commmandParts, " ")
-			return fmt.Errorf("error, when creating worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, cmd.Dir, output, err)
+			return fmt.Errorf("error, when restoreUncommittedWork() for createWorktree(). Error: %w", err)
 		}
 	}
-	err = ensureRemoteBranchesExists(worktreeDir, theEffort.BranchName)
-	if err != nil {
-		return fmt.Errorf("error, when ensureRemoteBranchExists() for createWorktree(). Error: %v", err)
+	if !remoteBranchExists {
+		err = ensureRemoteBranchExists(worktreeDir, theEffort.BranchName)
+		if err != nil {
+			return fmt.Errorf("error, when ensureRemoteBranchExists() for createWorktree(). Error: %w", err)
+		}
 	}
 	return nil
 }
 
-func ensureRemoteBranchesExists(worktreeDir string, branchName string) (err error) {
-	commandDir := worktreeDir
-	command := "git"
-
-	defer func() {
-		commandParts := []string{"switch", branchName}
-		cmd := exec.Command(command, commandParts...)
-		cmd.Dir = commandDir
-		output, cleanupErr := cmd.CombinedOutput()
-		if cleanupErr != nil {
-			commandString := command + " " + strings.Join(commandParts, " ")
-			err = fmt.Errorf(
-				"error, when executing command for ensureRemoteBranchExists(). Command: %s at directory: %s. Output: %s, Error: %v, Cleanup Error: %v",
-				commandString,
-				commandDir,
-				output,
-				err,
-				cleanupErr,
-			)
+// fetchEffortBranchStartPoint returns what a new local effort branch should start from, the remote effort branch if
+// it has already been pushed (e.g., from another machine) otherwise the freshly fetched remote trunk
+func fetchEffortBranchStartPoint(theEffort effort, r repo, remoteBranchExists bool) (string, error) {
+	commandDir := getBareRepoDir(r)
+	if remoteBranchExists {
+		refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", theEffort.BranchName, theEffort.BranchName)
+		_, err := runGitCommand(commandDir, "fetch", "origin", refspec)
+		if err != nil {
+			return "", fmt.Errorf("error, when fetching remote effort branch. Error: %w", err)
 		}
-	}()
-
-	commandParts := []string{"push", "-u", "origin", branchName}
-	cmd := exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf(
-			"error, when executing command for ensureRemoteBranchExists(). Command: %s at directory: %s. Output: %s, Error: %v",
-			commandString,
-			commandDir,
-			output,
-			err,
-		)
+		return "origin/" + theEffort.BranchName, nil
 	}
-
-	commandParts = []string{"switch", "master"}
-	cmd = exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err = cmd.CombinedOutput()
+	trunk, err := getTrunkBranch(r)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf(
-			"error, when executing command for ensureRemoteBranchExists(). Command: %s at directory: %s. Output: %s, Error: %v",
-			commandString,
-			commandDir,
-			output,
-			err,
-		)
+		return "", fmt.Errorf("error, when getTrunkBranch() for fetchEffortBranchStartPoint(). Error: %w", err)
 	}
-
-	commandParts = []string{"pull", "origin", "master"}
-	cmd = exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err = cmd.CombinedOutput()
+	err = fetchTrunk(r, trunk)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf(
-			"error, when executing command for ensureRemoteBranchExists(). Command: %s at directory: %s. Output: %s, Error: %v",
-			commandString,
-			commandDir,
-			output,
-			err,
-		)
+		return "", fmt.Errorf("error, when fetchTrunk() for fetchEffortBranchStartPoint(). Error: %w", err)
 	}
+	return "origin/" + trunk, nil
+}
 
-	commandParts = []string{"push", "-u", "origin", "master"}
-	cmd = exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err = cmd.CombinedOutput()
+// ensureRemoteBranchExists publishes the effort branch, the explicit refspec guarantees nothing but the effort branch
+// can be pushed
+func ensureRemoteBranchExists(worktreeDir string, branchName string) error {
+	refspec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", branchName, branchName)
+	_, err := runGitCommand(worktreeDir, "push", "--set-upstream", "origin", refspec)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf(
-			"error, when executing command for ensureRemoteBranchExists(). Command: %s at directory: %s. Output: %s, Error: %v",
-			commandString,
-			commandDir,
-			output,
-			err,
-		)
+		return fmt.Errorf("error, when pushing effort branch for ensureRemoteBranchExists(). Error: %w", err)
 	}
 	return nil
 }
 
-func verifySafeDeletionOfRemoteBranch(worktreeDir string, theEffort effort, r repo) (err error) {
-	commandDir := worktreeDir
-	command := "git"
-
-	defer func() {
-		commandParts := []string{"switch", theEffort.BranchName}
-		cmd := exec.Command(command, commandParts...)
-		cmd.Dir = commandDir
-		output, cleanupErr := cmd.CombinedOutput()
-		if cleanupErr != nil {
-			commandString := command + " " + strings.Join(commandParts, " ")
-			err = fmt.Errorf(
-				"error, when verifying if its safe to delete worktree with command: %s at directory: %s. Output: %s, Error: %v, Cleanup Error: %v",
-				commandString,
-				commandDir,
-				output,
-				err,
-				cleanupErr,
-			)
+// verifySafeDeletion makes sure removing the worktree and effort branch loses nothing. The worktree must be clean and
+// the branch, locally and on the remote since commits may have been pushed from elsewhere, must have made it into trunk.
+// It returns how the merge of the local branch was detected. An empty worktreeDir means the worktree is already gone and
+// the local branch is only checked when it still exists.
+func verifySafeDeletion(worktreeDir string, theEffort effort, r repo, remoteBranchExists bool) (mergeMethod, error) {
+	commandDir := getBareRepoDir(r)
+	var branches []string
+	if worktreeDir != "" {
+		dirty, err := isWorktreeDirty(worktreeDir)
+		if err != nil {
+			return "", fmt.Errorf("error, when isWorktreeDirty() for verifySafeDeletion(). Error: %w", err)
 		}
-	}()
+		if dirty {
+			return "", &dirtyWorktreeError{theEffort: theEffort, theRepo: r, WorktreeDir: worktreeDir}
+		}
+		branches = append(branches, theEffort.BranchName)
+	} else {
+		branchExists, err := doesBranchExist(theEffort.BranchName, commandDir)
+		if err != nil {
+			return "", fmt.Errorf("error, when doesBranchExist() for verifySafeDeletion(). Error: %w", err)
+		}
+		if branchExists {
+			branches = append(branches, theEffort.BranchName)
+		}
+	}
 
-	commandParts := []string{"status"}
-	cmd := exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err := cmd.CombinedOutput()
+	trunk, err := getTrunkBranch(r)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf("error, when verifying if its safe to delete worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
+		return "", fmt.Errorf("error, when getTrunkBranch() for verifySafeDeletion(). Error: %w", err)
 	}
-	if !strings.Contains(string(output), "working tree clean") {
-		return fmt.Errorf("unsafe delete operation, please stash or commit your changes. Effort: %s. Repo: %s", theEffort.Name, r.Title())
+	err = fetchTrunk(r, trunk)
+	if err != nil {
+		return "", fmt.Errorf("error, when fetchTrunk() for verifySafeDeletion(). Error: %w", err)
 	}
+	trunkRef := "origin/" + trunk
 
-	// pulling and pushing any existing changes on both the working branch and master to get local in sync with remote
-	// pulling first since remote should always be the source of truth
-	commandParts = []string{"pull"}
-	cmd = exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err = cmd.CombinedOutput()
+	if remoteBranchExists {
+		remoteRef := "origin/" + theEffort.BranchName
+		refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/%s", theEffort.BranchName, remoteRef)
+		_, err = runGitCommand(commandDir, "fetch", "origin", refspec)
+		if err != nil {
+			return "", fmt.Errorf("error, when fetching remote effort branch for verifySafeDeletion(). Error: %w", err)
+		}
+		branches = append(branches, remoteRef)
+	}
+
+	var localMethod mergeMethod
+	for _, branch := range branches {
+		method, err := detectMerge(commandDir, branch, theEffort.BranchName, trunkRef)
+		if err != nil {
+			return "", fmt.Errorf("error, when detectMerge() for verifySafeDeletion() of branch %s. Error: %w", branch, err)
+		}
+		if method == "" {
+			return "", &branchNotMergedError{theEffort: theEffort, theRepo: r, WorktreeDir: worktreeDir, Branch: branch, Trunk: trunkRef}
+		}
+		if localMethod == "" {
+			localMethod = method
+		}
+	}
+	return localMethod, nil
+}
+
+// deleteWorktree removes the worktree and the local and remote effort branch once verifySafeDeletion passes, the
+// returned detail says how the branch was found to be merged
+func deleteWorktree(theEffort effort, r repo) (string, error) {
+	worktreeDir := getWorktreeDir(theEffort, r)
+	commandDir := getBareRepoDir(r)
+	exists, err := checkDirectoryExists(worktreeDir)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf("error, when verifying if its safe to delete worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
+		return "", fmt.Errorf("error, when checkDirectoryExists() for deleteWorktree(). Error: %w", err)
 	}
-	commandParts = []string{"push"}
-	cmd = exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err = cmd.CombinedOutput()
+	if !exists {
+		return "", nil
+	}
+	branchExists, err := doesBranchExist(theEffort.BranchName, commandDir)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf("error, when verifying if its safe to delete worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
+		return "", fmt.Errorf("error, when doesBranchExist() for deleteWorktree(). Error: %w", err)
 	}
-	commandParts = []string{"switch", "master"}
-	cmd = exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err = cmd.CombinedOutput()
+	if !branchExists {
+		_, err = runGitCommand(commandDir, "worktree", "remove", worktreeDir)
+		if err != nil {
+			return "", fmt.Errorf("error, when removing worktree for deleteWorktree(). Error: %w", err)
+		}
+		return "", nil
+	}
+
+	err = ensureWorktreeIsOnCorrectBranch(worktreeDir, theEffort.BranchName)
+	if err != nil {
+		return "", fmt.Errorf("error, when ensureWorktreeIsOnCorrectBranch() for deleteWorktree(). Error: %w", err)
+	}
+	wip, err := isWipCommit(commandDir, theEffort.BranchName)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf("error, when verifying if its safe to delete worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
+		return "", fmt.Errorf("error, when isWipCommit() for deleteWorktree(). Error: %w", err)
+	}
+	if wip {
+		// the branch holds the uncommitted work so only the worktree goes, the WIP commit is undone on re-add
+		dirty, err := isWorktreeDirty(worktreeDir)
+		if err != nil {
+			return "", fmt.Errorf("error, when isWorktreeDirty() for deleteWorktree(). Error: %w", err)
+		}
+		if dirty {
+			return "", &dirtyWorktreeError{theEffort: theEffort, theRepo: r, WorktreeDir: worktreeDir}
+		}
+		_, err = runGitCommand(commandDir, "worktree", "remove", worktreeDir)
+		if err != nil {
+			return "", fmt.Errorf("error, when removing worktree for deleteWorktree(). Error: %w", err)
+		}
+		return "kept branch " + theEffort.BranchName + " since it ends in a WIP commit", nil
 	}
-	commandParts = []string{"pull"}
-	cmd = exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err = cmd.CombinedOutput()
+	remoteBranchExists, err := doesRemoteBranchExist(theEffort.BranchName, commandDir)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf("error, when verifying if its safe to delete worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
+		return "", fmt.Errorf("error, when doesRemoteBranchExist() for deleteWorktree(). Error: %w", err)
 	}
-	commandParts = []string{"push"}
-	cmd = exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err = cmd.CombinedOutput()
+	method, err := verifySafeDeletion(worktreeDir, theEffort, r, remoteBranchExists)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf("error, when verifying if its safe to delete worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
+		return "", fmt.Errorf("error, when verifySafeDeletion() for deleteWorktree(). Error: %w", err)
 	}
 
-	commandParts = []string{"branch", "--no-merged"}
-	cmd = exec.Command(command, commandParts...)
-	cmd.Dir = commandDir
-	output, err = cmd.CombinedOutput()
+	_, err = runGitCommand(commandDir, "worktree", "remove", worktreeDir)
 	if err != nil {
-		commandString := command + " " + strings.Join(commandParts, " ")
-		return fmt.Errorf("error, when verifying if its safe to delete worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
+		return "", fmt.Errorf("error, when removing worktree for deleteWorktree(). Error: %w", err)
 	}
-	if strings.Contains(string(output), theEffort.BranchName) {
-		err = fmt.Errorf(
-			"cannot delete the remote branch until it has been merged into master for effort: %s. repo: %s. Branch: %s",
-			theEffort.Name,
-			r.Title(),
-			theEffort.BranchName,
-		)
+	err = deleteMergedBranches(theEffort, r, true, remoteBranchExists)
+	if err != nil {
+		return "", fmt.Errorf("error, when deleteMergedBranches() for deleteWorktree(). Error: %w", err)
 	}
+	return "merged, detected by " + string(method), nil
+}
 
-	return err
+// deleteMergedBranches removes the local and remote effort branch, the merge must already have been verified
+func deleteMergedBranches(theEffort effort, r repo, branchExists bool, remoteBranchExists bool) error {
+	commandDir := getBareRepoDir(r)
+	if branchExists {
+		// -D since branch -d only understands regular merges and the merge has already been verified
+		_, err := runGitCommand(commandDir, "branch", "-D", theEffort.BranchName)
+		if err != nil {
+			return fmt.Errorf("error, when deleting local branch for deleteMergedBranches(). Error: %w", err)
+		}
+	}
+	if remoteBranchExists {
+		_, err := runGitCommand(commandDir, "push", "origin", "--delete", theEffort.BranchName)
+		if err != nil {
+			return fmt.Errorf("error, when deleting remote branch for deleteMergedBranches(). Error: %w", err)
+		}
+	}
+	return nil
+}
+
+// finishDeletingWorktree picks up a deleteWorktree that was interrupted, the worktree may already be gone while the
+// local or remote branch is left behind
+func finishDeletingWorktree(theEffort effort, r repo) (string, error) {
+	exists, err := checkDirectoryExists(getWorktreeDir(theEffort, r))
+	if err != nil {
+		return "", fmt.Errorf("error, when checkDirectoryExists() for finishDeletingWorktree(). Error: %w", err)
+	}
+	if exists {
+		return deleteWorktree(theEffort, r)
+	}
+	commandDir := getBareRepoDir(r)
+	branchExists, err := doesBranchExist(theEffort.BranchName, commandDir)
+	if err != nil {
+		return "", fmt.Errorf("error, when doesBranchExist() for finishDeletingWorktree(). Error: %w", err)
+	}
+	remoteBranchExists, err := doesRemoteBranchExist(theEffort.BranchName, commandDir)
+	if err != nil {
+		return "", fmt.Errorf("error, when doesRemoteBranchExist() for finishDeletingWorktree(). Error: %w", err)
+	}
+	if !branchExists && !remoteBranchExists {
+		return "already deleted", nil
+	}
+	method, err := verifySafeDeletion("", theEffort, r, remoteBranchExists)
+	if err != nil {
+		return "", fmt.Errorf("error, when verifySafeDeletion() for finishDeletingWorktree(). Error: %w", err)
+	}
+	err = deleteMergedBranches(theEffort, r, branchExists, remoteBranchExists)
+	if err != nil {
+		return "", fmt.Errorf("error, when deleteMergedBranches() for finishDeletingWorktree(). Error: %w", err)
+	}
+	return "merged, detected by " + string(method), nil
 }
 
-func deleteWorktree(theEffort effort, r repo) error {
+// forceDeleteWorktree removes the worktree along with the local and remote effort branch and any stashed uncommitted
+// work whether or not they hold work that exists nowhere else
+func forceDeleteWorktree(theEffort effort, r repo) (string, error) {
 	worktreeDir := getWorktreeDir(theEffort, r)
-	command := "git"
-	commandDir := reposDirectory + r.getRepoDirectoryName()
+	commandDir := getBareRepoDir(r)
 	exists, err := checkDirectoryExists(worktreeDir)
 	if err != nil {
-		return fmt.Errorf("error, when checkDirectoryExists() for deteletWorktree(). Error: %v", err)
+		return "", fmt.Errorf("error, when checkDirectoryExists() for forceDeleteWorktree(). Error: %w", err)
 	}
 	if exists {
-		// we delete the branches first because we need the worktree directory to perform validation
-		branchExists, err := doesBranchExist(theEffort.BranchName, commandDir)
+		_, err = runGitCommand(commandDir, "worktree", "remove", "--force", "--force", worktreeDir)
 		if err != nil {
-			return fmt.Errorf("error, when doesBranchExist() for deteletWorktree. Error: %v", err)
+			return "", fmt.Errorf("error, when removing worktree for forceDeleteWorktree(). Error: %w", err)
 		}
-		if branchExists {
-			err := ensureWorktreeIsOnCorrectBranch(worktreeDir, theEffort.BranchName)
-			if err != nil {
-				return fmt.Errorf("error, when ensureWorktreeIsOnCorrectBranch() for deteletWorktree(). Error: %v", err)
-			}
-
-			remoteBranchExists, err := doesRemoteBranchExist(theEffort.BranchName, commandDir)
-			if err != nil {
-				return fmt.Errorf("error, when doesBranchExist() for deteletWorktree. Error: %v", err)
-			}
-			if remoteBranchExists {
-				err = verifySafeDeletionOfRemoteBranch(worktreeDir, theEffort, r)
-				if err != nil {
-					return fmt.Errorf("error, when verifySafeDeleteOfRemoteBranch() for deleteWorktree(). Error: %v", err)
-				}
-				deleteBranchRemoteCmdParts := []string{"push", "origin", "--delete", theEffort.BranchName}
-				deleteBranchRemoteCmd := exec.Command(command, deleteBranchRemoteCmdParts...)
-				deleteBranchRemoteCmd.Dir = commandDir
-				output, err := deleteBranchRemoteCmd.CombinedOutput()
-				if err != nil {
-					commandString := command + " " + strings.Join(deleteBranchRemoteCmdParts, " ")
-					return fmt.Errorf("error, when deleting remote branch: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
-				}
-			}
-
-			// cannot delete a branch while we are on that branch, so switching to master
-			commandParts := []string{"switch", "master"}
-			cmd := exec.Command(command, commandParts...)
-			cmd.Dir = worktreeDir
-			output, err := cmd.CombinedOutput()
-			if err != nil {
-				commandString := command + " " + strings.Join(commandParts, " ")
-				return fmt.Errorf(
-					"error, when verifying if its safe to delete worktree with command: %s at directory: %s. Output: %s, Error: %v",
-					commandString,
-					commandDir,
-					output,
-					err,
-				)
-			}
-
-			deleteBranchCmdParts := []string{"branch", "-d", theEffort.BranchName}
-			deleteBranchCmd := exec.Command(command, deleteBranchCmdParts...)
-			deleteBranchCmd.Dir = commandDir
-			output, err = deleteBranchCmd.CombinedOutput()
-			if err != nil {
-				commandString := command + " " + strings.Join(deleteBranchCmdParts, " ")
-				return fmt.Errorf("error, when deleting worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
-			}
+	}
+	branchExists, err := doesBranchExist(theEffort.BranchName, commandDir)
+	if err != nil {
+		return "", fmt.Errorf("error, when doesBranchExist() for forceDeleteWorktree(). Error: %w", err)
+	}
+	if branchExists {
+		_, err = runGitCommand(commandDir, "branch", "-D", theEffort.BranchName)
+		if err != nil {
+			return "", fmt.Errorf("error, when deleting local branch for forceDeleteWorktree(). Error: %w", err)
 		}
-
-		commmandParts := []string{"worktree", "remove", worktreeDir}
-		cmd := exec.Command(command, commmandParts...)
-		cmd.Dir = commandDir
-		output, err := cmd.CombinedOutput()
+	}
+	remoteBranchExists, err := doesRemoteBranchExist(theEffort.BranchName, commandDir)
+	if err != nil {
+		return "", fmt.Errorf("error, when doesRemoteBranchExist() for forceDeleteWorktree(). Error: %w", err)
+	}
+	if remoteBranchExists {
+		_, err = runGitCommand(commandDir, "push", "origin", "--delete", theEffort.BranchName)
 		if err != nil {
-			commandString := command + " " + strings.Join(commmandParts, " ")
-			return fmt.Errorf("error, when deleting worktree with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
+			return "", fmt.Errorf("error, when deleting remote branch for forceDeleteWorktree(). Error: %w", err)
 		}
 	}
-
-	return nil
+	stashRef := getUncommittedWorkRef(theEffort.BranchName)
+	_, err = runGitCommand(commandDir, "rev-parse", "--verify", "--quiet", stashRef)
+	if err == nil {
+		_, err = runGitCommand(commandDir, "update-ref", "-d", stashRef)
+		if err != nil {
+			return "", fmt.Errorf("error, when removing stash ref for forceDeleteWorktree(). Error: %w", err)
+		}
+	}
+	return "force deleted", nil
 }
 
 func ensureWorktreeIsOnCorrectBranch(worktreeDir string, branchName string) error {
 	command := "git"
 	commandDir := worktreeDir
 	commmandParts := []string{"switch", branchName}
-	cmd := exec.Command(command, commmandParts...)
-	cmd.Dir = commandDir
-	output, err := cmd.CombinedOutput()
+	output, err := runGitCombinedOutput(commandDir, commmandParts...)
 	if err != nil {
 		commandString := command + " " + strings.Join(commmandParts, " ")
-		return fmt.Errorf("error, when ensuring worktree is on the correct branch with command: %s at directory: %s. Output: %s, Error: %v", commandString, commandDir, output, err)
+		return fmt.Errorf("error, when ensuring worktree is on the correct branch with command: %s at directory: %s. Output: %s, Error: %w", commandString, commandDir, output, err)
 	}
 	return nil
 }
@@ -513,7 +641,7 @@ func ensureWorktreeIsOnCorrectBranch(worktreeDir string, branchName string) erro
 func persistRepoSelection(effortId int64, repos []repo) error {
 	err := deleteAnyNoLongerSelected(effortId, repos)
 	if err != nil {
-		return fmt.Errorf("error, when deleteAnyNoLongerSelected() for persistRepoSelection(). Error: %v", err)
+		return fmt.Errorf("error, when deleteAnyNoLongerSelected() for persistRepoSelection(). Error: %w", err)
 	}
 
 	till := len(repos) * 2
@@ -530,7 +658,11 @@ func persistRepoSelection(effortId int64, repos []repo) error {
 		args...,
 	)
 	if err != nil {
-		return fmt.Errorf("error, when executing insert records statement for persistRepoSelection(). Error: %v", err)
+		return fmt.Errorf("error, when executing insert records statement for persistRepoSelection(). Error: %w", err)
+	}
+	err = markEffortUpdated(effortId)
+	if err != nil {
+		return fmt.Errorf("error, when markEffortUpdated() for persistRepoSelection(). Error: %w", err)
 	}
 	return nil
 }
@@ -574,7 +706,7 @@ func deleteAnyNoLongerSelected(effortId int64, repos []repo) error {
 		args...,
 	)
 	if err != nil {
-		return fmt.Errorf("error, when executing sql statement. Statement: %s. Error: %v", deleteStatement, err)
+		return fmt.Errorf("error, when executing sql statement. Statement: %s. Error: %w", deleteStatement, err)
 	}
 	return nil
 }
@@ -599,7 +731,7 @@ func fetchSelectedReposForEffort(effortId int64) (map[int64]bool, error) {
 	}(rows)
 
 	if err != nil {
-		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %v", err)
+		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %w", err)
 	}
 
 	// selectedReposMap key is repo id and value doesn't matter
@@ -610,14 +742,14 @@ func fetchSelectedReposForEffort(effortId int64) (map[int64]bool, error) {
 			&id,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error, when scanning database rows. Error: %v", err)
+			return nil, fmt.Errorf("error, when scanning database rows. Error: %w", err)
 		}
 		selectedReposMap[id] = true
 	}
 
 	err = rows.Err()
 	if err != nil {
-		return nil, fmt.Errorf("error, when iterating through database rows. Error: %v", err)
+		return nil, fmt.Errorf("error, when iterating through database rows. Error: %w", err)
 	}
 	return selectedReposMap, nil
 }
@@ -625,7 +757,7 @@ func fetchSelectedReposForEffort(effortId int64) (map[int64]bool, error) {
 func fetchEffortRepoChoices(effortId int64, allRepos list.Model) ([]list.Item, error) {
 	selectedReposMap, err := fetchSelectedReposForEffort(effortId)
 	if err != nil {
-		return nil, fmt.Errorf("error, when fetchSelectedReposForEffort() for fetchEffortRepoChoices(). Error: %v", err)
+		return nil, fmt.Errorf("error, when fetchSelectedReposForEffort() for fetchEffortRepoChoices(). Error: %w", err)
 	}
 	result := make([]list.Item, len(allRepos.Items()))
 	for i, r := range allRepos.Items() {
@@ -644,28 +776,24 @@ func fetchEffortRepoChoices(effortId int64, allRepos list.Model) ([]list.Item, e
 }
 
 func doesBranchExist(branchName string, commandDir string) (bool, error) {
-	cmd := exec.Command("git", "rev-parse", "--verify", branchName)
-	cmd.Dir = commandDir
-	output, err := cmd.CombinedOutput()
+	output, err := runGitCombinedOutput(commandDir, "rev-parse", "--verify", branchName)
 	if err != nil {
 		outputString := string(output)
 		if strings.Contains(outputString, "Needed a single revision") {
 			return false, nil // Branch does not exist
 		}
 		// If there was another error, return it
-		return false, fmt.Errorf("error, when running command. Output %s. Error: %v", output, err)
+		return false, fmt.Errorf("error, when running command. Output %s. Error: %w", output, err)
 	}
 	// If no error, the branch exists
 	return true, nil
 }
 
 func doesRemoteBranchExist(branchName string, commandDir string) (bool, error) {
-	cmd := exec.Command("git", "ls-remote", "--heads", "origin", branchName)
-	cmd.Dir = commandDir
-	output, err := cmd.CombinedOutput()
+	output, err := runGitCombinedOutput(commandDir, "ls-remote", "--heads", "origin", branchName)
 	if err != nil {
 		// If there was another error, return it
-		return false, fmt.Errorf("error, when running command. Output %s. Error: %v", output, err)
+		return false, fmt.Errorf("error, when running command. Output %s. Error: %w", output, err)
 	}
 	outputString := string(output)
 	if !strings.Contains(outputString, branchName) {
@@ -681,47 +809,118 @@ func checkDirectoryExists(path string) (bool, error) {
 		return false, nil
 	}
 	if err != nil {
-		return false, fmt.Errorf("error, when checking if directory exists for checkDirectoryExists(). Error: %v", err)
+		return false, fmt.Errorf("error, when checking if directory exists for checkDirectoryExists(). Error: %w", err)
 	}
 	return info.IsDir(), nil
 }
 
-func deleteEffort(theEffort effort) error {
+// deleteEffort removes the worktrees and branches of the effort then the effort itself. force skips the checks that
+// protect unmerged and uncommitted work, saving that work to the recovery directory first instead. The results say how
+// each branch was found to be merged or where its work was saved.
+func deleteEffort(theEffort effort, force bool) (results []repoResult, err error) {
+	start := time.Now()
+	defer func() {
+		action := "delete effort"
+		if force {
+			action = "force delete effort"
+		}
+		logEvent(action, theEffort.Name, start, err)
+	}()
+	_, err = backupDatabase("pre_delete_effort")
+	if err != nil {
+		return nil, fmt.Errorf("error, when backupDatabase() for deleteEffort(). Error: %w", err)
+	}
 	repoIds, err := fetchSelectedReposForEffort(theEffort.Id)
 	if err != nil {
-		return fmt.Errorf("error, when fetchSelectedReposForEffort() for deleteEffort(). Error: %v", err)
+		return nil, fmt.Errorf("error, when fetchSelectedReposForEffort() for deleteEffort(). Error: %w", err)
 	}
 	effortRepos, err := fetchReposForIds(repoIds)
 	if err != nil {
-		return fmt.Errorf("error, when fetchReposForIds() for deleteEffort(). Error: %v", err)
+		return nil, fmt.Errorf("error, when fetchReposForIds() for deleteEffort(). Error: %w", err)
 	}
-	if len(effortRepos) != 0 {
-		for _, r := range effortRepos {
-			err = deleteWorktree(theEffort, r)
-			if err != nil {
-				return fmt.Errorf("error, when deleteWorktree() for deleteEffort(). Error: %v", err)
+	preservedRepos, err := fetchReposWithPreservedWork(theEffort)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposWithPreservedWork() for deleteEffort(). Error: %w", err)
+	}
+	if len(preservedRepos) != 0 {
+		if !force {
+			preservedErr := &preservedWorkError{theEffort: theEffort}
+			for _, r := range preservedRepos {
+				preservedErr.Repos = append(preservedErr.Repos, r.Title())
+			}
+			return nil, preservedErr
+		}
+		// repos no longer in the effort are force deleted too so the work they preserved is bundled along with the rest
+		for _, r := range preservedRepos {
+			if !repoIds[r.Id] {
+				effortRepos = append(effortRepos, r)
 			}
 		}
-		theStatement := `DELETE FROM effort_repo
-                    WHERE effort_id = ?`
-		_, err = database.Exec(theStatement, theEffort.Id)
+	}
+	var steps []journalStep
+	var saved []repoResult
+	var recoveryDir string
+	for _, r := range effortRepos {
+		if force {
+			steps = append(steps, journalStep{Action: journalActionForceDeleteWorktree, theRepo: r})
+		} else {
+			steps = append(steps, journalStep{Action: journalActionDeleteWorktree, theRepo: r})
+		}
+	}
+	steps = append(steps, journalStep{Action: journalActionDeleteEffortRecord})
+	if force && len(effortRepos) != 0 {
+		// saving changes nothing so it happens before the journal, a crash here leaves nothing to recover
+		recoveryDir, saved, err = saveUnmergedWork(theEffort, effortRepos)
 		if err != nil {
-			return fmt.Errorf("error, when deleting from effort_repo table for deleteEffort(). Error: %v", err)
+			return nil, fmt.Errorf("error, when saveUnmergedWork() for deleteEffort(), nothing was deleted. Error: %w", err)
 		}
 	}
+	journal, err := beginJournal(operationKindDeleteEffort, theEffort, force, recoveryDir, steps)
+	if err != nil {
+		return nil, fmt.Errorf("error, when beginJournal() for deleteEffort(). Error: %w", err)
+	}
+	results, err = journal.finishAfter(runJournalSteps(journal))
+	if err != nil {
+		return nil, fmt.Errorf("error, when runJournalSteps() for deleteEffort(). Error: %w", err)
+	}
+	savedDetails := make(map[string]string, len(saved))
+	for _, s := range saved {
+		savedDetails[s.theRepo.Url] = s.Detail
+	}
+	for i := range results {
+		if detail, ok := savedDetails[results[i].theRepo.Url]; ok && results[i].Outcome == repoOutcomeSucceeded {
+			results[i].Detail += ", " + detail
+		}
+	}
+	return results, nil
+}
+
+// deleteEffortRecord removes the effort and its repo selection in one transaction then its now empty directory
+func deleteEffortRecord(theEffort effort) error {
+	tx, err := database.Begin()
+	if err != nil {
+		return fmt.Errorf("error, when starting transaction for deleteEffortRecord(). Error: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(`DELETE FROM effort_repo WHERE effort_id = ?`, theEffort.Id)
+	if err != nil {
+		return fmt.Errorf("error, when deleting from effort_repo table for deleteEffortRecord(). Error: %w", err)
+	}
+	_, err = tx.Exec(`DELETE FROM effort WHERE id = ?`, theEffort.Id)
+	if err != nil {
+		return fmt.Errorf("error, when deleting from effort table for deleteEffortRecord(). Error: %w", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error, when committing transaction for deleteEffortRecord(). Error: %w", err)
+	}
 	effortDir, err := getEffortDir(theEffort.Name)
 	if err != nil {
-		return fmt.Errorf("error, when getEffortDir() for deleteEffort(). Error: %v", err)
+		return fmt.Errorf("error, when getEffortDir() for deleteEffortRecord(). Error: %w", err)
 	}
 	err = os.Remove(effortDir)
 	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("error, when os.Remove() for deleteEffort(). Error: %v", err)
-	}
-	theStatement := `DELETE FROM effort
-                    WHERE id = ?`
-	_, err = database.Exec(theStatement, theEffort.Id)
-	if err != nil {
-		return fmt.Errorf("error, when deleting from effort table for deleteEffort(). Error: %v", err)
+		return fmt.Errorf("error, when os.Remove() for deleteEffortRecord(). Error: %w", err)
 	}
 	return nil
 }
@@ -741,7 +940,7 @@ func fetchReposForIds(repoIds map[int64]bool) ([]repo, error) {
 		args[i] = k
 		i++
 	}
-	theStatement := `SELECT url
+	theStatement := `SELECT id, url, COALESCE(trunk_branch, '')
                     FROM repo
                     WHERE id IN (%s)`
 	theStatement = fmt.Sprintf(theStatement, strings.Join(placeholders, ","))
@@ -762,32 +961,49 @@ func fetchReposForIds(repoIds map[int64]bool) ([]repo, error) {
 	}(rows)
 
 	if err != nil {
-		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %v", err)
+		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %w", err)
 	}
 
 	var result []repo
 	for rows.Next() {
 		var r repo
 		err = rows.Scan(
+			&r.Id,
 			&r.Url,
+			&r.TrunkBranch,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error, when scanning database rows. Error: %v", err)
+			return nil, fmt.Errorf("error, when scanning database rows. Error: %w", err)
 		}
 		result = append(result, r)
 	}
 
 	err = rows.Err()
 	if err != nil {
-		return nil, fmt.Errorf("error, when iterating through database rows. Error: %v", err)
+		return nil, fmt.Errorf("error, when iterating through database rows. Error: %w", err)
 	}
 	return result, nil
 }
 
+func fetchReposForEffort(effortId int64) ([]repo, error) {
+	repoIds, err := fetchSelectedReposForEffort(effortId)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchSelectedReposForEffort() for fetchReposForEffort(). Error: %w", err)
+	}
+	if len(repoIds) == 0 {
+		return []repo{}, nil
+	}
+	effortRepos, err := fetchReposForIds(repoIds)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposForIds() for fetchReposForEffort(). Error: %w", err)
+	}
+	return effortRepos, nil
+}
+
 func getEffortDir(name string) (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("error, when fetching users home directory. Error: %v", err)
+		return "", fmt.Errorf("error, when fetching users home directory. Error: %w", err)
 	}
 	return homeDir + "/git_tool_data/efforts/" + name, nil
 }
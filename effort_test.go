@@ -20,3 +20,38 @@ func Test_generateInsertStatement(t *testing.T) {
 		t.Errorf("got %s, but wanted %s", got, expected)
 	}
 }
+
+func Test_addEffort_taken(t *testing.T) {
+	openTestDatabase(t)
+	t.Setenv("HOME", t.TempDir())
+
+	validationMsg, err := addEffort("Billing Fix", "ABC-1")
+	if err != nil || validationMsg != "" {
+		t.Fatalf("got %q and %v, but wanted the effort created", validationMsg, err)
+	}
+	tests := []struct {
+		name       string
+		branchName string
+		expected   string
+	}{
+		{"billing fix", "ABC-2", "an effort named billing_fix already exists"},
+		{"Other", "ABC-1", "effort billing_fix already uses the branch ABC-1"},
+	}
+	for _, test := range tests {
+		validationMsg, err = addEffort(test.name, test.branchName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if validationMsg != test.expected {
+			t.Errorf("got %q for %s on %s, but wanted %q", validationMsg, test.name, test.branchName, test.expected)
+		}
+	}
+	var count int
+	err = database.QueryRow(`SELECT COUNT(*) FROM effort`).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %d efforts, but wanted only the first one", count)
+	}
+}
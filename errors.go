@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// dirtyWorktreeError stops a worktree from being removed while it has uncommitted changes
+type dirtyWorktreeError struct {
+	theEffort   effort
+	theRepo     repo
+	WorktreeDir string
+}
+
+func (e *dirtyWorktreeError) Error() string {
+	return fmt.Sprintf("unsafe delete operation, please stash or commit your changes. Effort: %s. Repo: %s", e.theEffort.Name, e.theRepo.Title())
+}
+
+// branchNotMergedError stops the remote branch of an effort from being deleted before it has been merged into trunk
+type branchNotMergedError struct {
+	theEffort   effort
+	theRepo     repo
+	WorktreeDir string
+	// Branch is the local effort branch or its remote tracking ref, whichever has changes that aren't in Trunk
+	Branch string
+	Trunk  string
+}
+
+func (e *branchNotMergedError) Error() string {
+	return fmt.Sprintf(
+		"cannot delete the branch until it has been merged into %s for effort: %s. repo: %s. Branch: %s. Checked ancestry, patch ids, identical files and the combined patch id for a squash merge",
+		e.Trunk,
+		e.theEffort.Name,
+		e.theRepo.Title(),
+		e.Branch,
+	)
+}
+
+// preservedWorkError stops an effort from being deleted while uncommitted work preserveUncommittedWork kept in git is
+// still waiting to be put back, once the effort is gone nothing would restore it
+type preservedWorkError struct {
+	theEffort effort
+	Repos     []string
+}
+
+func (e *preservedWorkError) Error() string {
+	return fmt.Sprintf(
+		"unsafe delete operation, uncommitted work was saved as a stash or WIP commit of branch %s in these repos: %s. Add the repos back to restore it, or force delete to bundle it into the recovery directory. Effort: %s",
+		e.theEffort.BranchName,
+		strings.Join(e.Repos, ", "),
+		e.theEffort.Name,
+	)
+}
+
+// repoInUseError stops a repo from being deleted while efforts still have worktrees of it
+type repoInUseError struct {
+	theRepo repo
+	Efforts []string
+}
+
+func (e *repoInUseError) Error() string {
+	return fmt.Sprintf("unsafe delete operation, the %s repo is still being used by these efforts: %s", e.theRepo.Url, strings.Join(e.Efforts, ", "))
+}
+
+// authError is a git command the remote refused because of missing or wrong credentials
+type authError struct {
+	Output string
+	Err    error
+}
+
+func (e *authError) Error() string {
+	return fmt.Sprintf("authentication with the remote failed, check your ssh key is loaded (ssh-add -l). Error: %v", e.Err)
+}
+
+func (e *authError) Unwrap() error {
+	return e.Err
+}
+
+// networkError is a git command that couldn't reach the remote
+type networkError struct {
+	Output string
+	Err    error
+}
+
+func (e *networkError) Error() string {
+	return fmt.Sprintf("could not reach the remote, check your network connection. Error: %v", e.Err)
+}
+
+func (e *networkError) Unwrap() error {
+	return e.Err
+}
+
+// authFailureOutputs and networkFailureOutputs are what git and ssh print for each kind of failure
+var authFailureOutputs = []string{
+	"Permission denied (publickey",
+	"Host key verification failed",
+	"Authentication failed",
+	"could not read Username",
+	"terminal prompts disabled",
+}
+
+var networkFailureOutputs = []string{
+	"Could not resolve hostname",
+	"Could not resolve host",
+	"Connection timed out",
+	"Connection refused",
+	"Network is unreachable",
+	"Connection reset by peer",
+	"Operation timed out",
+}
+
+// classifyGitError turns a failed git command into an authError or networkError when its output says that's what
+// went wrong, any other error is returned unchanged
+func classifyGitError(output string, err error) error {
+	for _, text := range authFailureOutputs {
+		if strings.Contains(output, text) {
+			return &authError{Output: output, Err: err}
+		}
+	}
+	for _, text := range networkFailureOutputs {
+		if strings.Contains(output, text) {
+			return &networkError{Output: output, Err: err}
+		}
+	}
+	return err
+}
+
+// errorAction is a key the error screen offers for dealing with the error
+type errorAction struct {
+	Key         string
+	Description string
+}
+
+// errorActions are the keys offered for err, canRetry is whether the operation that failed can be run again
+func errorActions(err error, canRetry bool) []errorAction {
+	var actions []errorAction
+	if _, _, ok := errorWorktree(err); ok {
+		actions = append(actions, errorAction{Key: "o", Description: "open worktree"})
+	}
+	if theEffort, ok := errorForceDeletableEffort(err); ok {
+		actions = append(actions, errorAction{Key: "f", Description: fmt.Sprintf("force delete effort %s", theEffort.Name)})
+	}
+	var dirty *dirtyWorktreeError
+	if canRetry && errors.As(err, &dirty) {
+		actions = append(
+			actions,
+			errorAction{Key: "s", Description: "stash changes and retry"},
+			errorAction{Key: "p", Description: "export changes as a patch and retry"},
+			errorAction{Key: "w", Description: "commit changes as WIP and retry"},
+		)
+	}
+	var inUse *repoInUseError
+	if errors.As(err, &inUse) {
+		actions = append(actions, errorAction{Key: "e", Description: "show efforts"})
+	}
+	if canRetry {
+		actions = append(actions, errorAction{Key: "r", Description: "retry"})
+	}
+	return append(actions, errorAction{Key: "esc", Description: "dismiss"})
+}
+
+// errorUncommittedWorkModes are the error screen keys that preserve the changes blocking a delete before retrying it
+var errorUncommittedWorkModes = map[string]uncommittedWorkMode{
+	"s": uncommittedWorkStash,
+	"p": uncommittedWorkPatch,
+	"w": uncommittedWorkWip,
+}
+
+// errorWorktree returns the worktree that blocked a delete, ok is false for any other error
+func errorWorktree(err error) (theEffort effort, worktreeDir string, ok bool) {
+	var dirty *dirtyWorktreeError
+	if errors.As(err, &dirty) {
+		return dirty.theEffort, dirty.WorktreeDir, true
+	}
+	var notMerged *branchNotMergedError
+	if errors.As(err, &notMerged) {
+		return notMerged.theEffort, notMerged.WorktreeDir, true
+	}
+	return effort{}, "", false
+}
+
+// errorForceDeletableEffort returns the effort whose delete was refused to protect work that a force delete saves
+// to the recovery directory, ok is false for any other error
+func errorForceDeletableEffort(err error) (theEffort effort, ok bool) {
+	if theEffort, _, ok := errorWorktree(err); ok {
+		return theEffort, true
+	}
+	var preserved *preservedWorkError
+	if errors.As(err, &preserved) {
+		return preserved.theEffort, true
+	}
+	return effort{}, false
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func Test_classifyGitError(t *testing.T) {
+	cause := errors.New("exit status 128")
+	var auth *authError
+	if !errors.As(classifyGitError("git@github.com: Permission denied (publickey).", cause), &auth) {
+		t.Errorf("wanted an authError for a publickey failure")
+	}
+	var network *networkError
+	if !errors.As(classifyGitError("ssh: Could not resolve hostname github.com", cause), &network) {
+		t.Errorf("wanted a networkError for a dns failure")
+	}
+	if got := classifyGitError("fatal: not a git repository", cause); got != cause {
+		t.Errorf("got %v, but wanted the original error", got)
+	}
+	if !errors.Is(classifyGitError("Connection refused", cause), cause) {
+		t.Errorf("wanted the classified error to unwrap to its cause")
+	}
+}
+
+func Test_errorActions(t *testing.T) {
+	theEffort := effort{Name: "feature"}
+	wrapped := fmt.Errorf("error, when deleteWorktree() for deleteEffort(). Error: %w", &branchNotMergedError{theEffort: theEffort})
+	got := errorActions(wrapped, true)
+	var keys string
+	for _, action := range got {
+		keys += action.Key + " "
+	}
+	if keys != "o f r esc " {
+		t.Errorf("got keys %s, but wanted o f r esc", keys)
+	}
+	dirty := fmt.Errorf("error, when deleteWorktree(). Error: %w", &dirtyWorktreeError{theEffort: theEffort})
+	keys = ""
+	for _, action := range errorActions(dirty, true) {
+		keys += action.Key + " "
+	}
+	if keys != "o f s p w r esc " {
+		t.Errorf("got keys %s, but wanted o f s p w r esc", keys)
+	}
+	got = errorActions(errors.New("boom"), false)
+	if len(got) != 1 || got[0].Key != "esc" {
+		t.Errorf("got %v, but wanted only esc", got)
+	}
+}
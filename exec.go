@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// execResult is the outcome of running a command in one worktree, Output keeps everything it printed
+type execResult struct {
+	repoResult
+	Output string
+}
+
+// execInEffort runs the command in every worktree of the effort with at most concurrency running at once. Output is
+// streamed to out with each line prefixed by the repo name.
+func execInEffort(theEffort effort, command []string, concurrency int, out io.Writer) ([]execResult, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("must provide a command to run")
+	}
+	effortRepos, err := fetchReposForEffort(theEffort.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposForEffort() for execInEffort(). Error: %w", err)
+	}
+
+	width := 0
+	for _, r := range effortRepos {
+		width = max(width, len(r.Title()))
+	}
+
+	var outMutex sync.Mutex
+	results := make([]execResult, len(effortRepos))
+	runWorkerPool(len(effortRepos), concurrency, func(i int) {
+		r := effortRepos[i]
+		prefix := fmt.Sprintf("[%-*s] ", width, r.Title())
+		results[i] = execInWorktree(theEffort, r, command, &prefixWriter{prefix: prefix, out: out, mutex: &outMutex})
+		results[i].repoResult = markIfCancelled(results[i].repoResult)
+	})
+	return results, nil
+}
+
+func execInWorktree(theEffort effort, r repo, command []string, out *prefixWriter) execResult {
+	result := execResult{repoResult: repoResult{theRepo: r}}
+	worktreeDir := getWorktreeDir(theEffort, r)
+	exists, err := checkDirectoryExists(worktreeDir)
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+	if !exists {
+		result.Outcome = repoOutcomeSkipped
+		result.Detail = "worktree is missing"
+		return result
+	}
+
+	var output bytes.Buffer
+	writer := io.MultiWriter(&output, out)
+	cmd := exec.CommandContext(operationContext(), command[0], command[1:]...)
+	cmd.Dir = worktreeDir
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	// processes started by the command may keep its output open after it is killed, this stops Wait from blocking on it
+	cmd.WaitDelay = 5 * time.Second
+	start := time.Now()
+	err = cmd.Run()
+	out.flush()
+	duration := time.Since(start).Round(time.Millisecond)
+	result.Output = output.String()
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = fmt.Sprintf("%v after %s", err, duration)
+		return result
+	}
+	result.Outcome = repoOutcomeSucceeded
+	result.Detail = fmt.Sprintf("exit status 0 after %s", duration)
+	return result
+}
+
+// prefixWriter writes complete lines to out with a prefix, partial lines are held until they are completed so output
+// from concurrent commands doesn't interleave mid line
+type prefixWriter struct {
+	prefix  string
+	out     io.Writer
+	mutex   *sync.Mutex
+	partial []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.partial[:i+1])
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *prefixWriter) flush() {
+	if len(w.partial) != 0 {
+		w.writeLine(append(w.partial, '\n'))
+		w.partial = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	if w.out == nil {
+		return
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+}
+
+func formatExecResults(title string, results []execResult, outputLines int) string {
+	repoResults := make([]repoResult, len(results))
+	var outputs []string
+	for i, r := range results {
+		repoResults[i] = r.repoResult
+		if outputLines > 0 && strings.TrimSpace(r.Output) != "" {
+			outputs = append(outputs, fmt.Sprintf("%s:\n%s", r.theRepo.Title(), lastLines(r.Output, outputLines)))
+		}
+	}
+	report := formatRepoResults(title, repoResults)
+	if len(outputs) != 0 {
+		report += "\n\n" + strings.Join(outputs, "\n\n")
+	}
+	return report
+}
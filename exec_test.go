@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_execInWorktree(t *testing.T) {
+	previousEffortsDirectory := effortsDirectory
+	effortsDirectory = t.TempDir() + "/"
+	t.Cleanup(func() {
+		effortsDirectory = previousEffortsDirectory
+	})
+	theEffort := effort{Name: "feature", BranchName: "ABC-1"}
+	r := repo{Url: "git@example.com:team/app.git", TrunkBranch: "main"}
+
+	var out bytes.Buffer
+	writer := &prefixWriter{prefix: "[app] ", out: &out, mutex: &sync.Mutex{}}
+	result := execInWorktree(theEffort, r, []string{"sh", "-c", "exit 3"}, writer)
+	if result.Outcome != repoOutcomeSkipped {
+		t.Errorf("got %s: %s, but wanted a missing worktree skipped", result.Outcome, result.Detail)
+	}
+
+	err := os.MkdirAll(getWorktreeDir(theEffort, r), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name            string
+		command         []string
+		expectedOutcome repoOutcome
+		expectedDetail  string
+		expectedOutput  string
+	}{
+		{
+			name:            "succeeds",
+			command:         []string{"sh", "-c", "echo one; printf two"},
+			expectedOutcome: repoOutcomeSucceeded,
+			expectedDetail:  "exit status 0",
+			expectedOutput:  "[app] one\n[app] two\n",
+		},
+		{
+			name:            "keeps the exit code",
+			command:         []string{"sh", "-c", "echo failing >&2; exit 3"},
+			expectedOutcome: repoOutcomeFailed,
+			expectedDetail:  "exit status 3",
+			expectedOutput:  "[app] failing\n",
+		},
+		{
+			name:            "command not found",
+			command:         []string{"git-tool-no-such-command"},
+			expectedOutcome: repoOutcomeFailed,
+			expectedDetail:  "executable file not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			result := execInWorktree(theEffort, r, tt.command, writer)
+			if result.Outcome != tt.expectedOutcome || !strings.Contains(result.Detail, tt.expectedDetail) {
+				t.Errorf("got %s: %s, but wanted %s: %s", result.Outcome, result.Detail, tt.expectedOutcome, tt.expectedDetail)
+			}
+			if out.String() != tt.expectedOutput {
+				t.Errorf("got output %q, but wanted %q", out.String(), tt.expectedOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// networkGitCommands talk to the remote so they get gitNetworkTimeoutSeconds instead of gitTimeoutSeconds
+var networkGitCommands = map[string]bool{
+	"clone":     true,
+	"fetch":     true,
+	"pull":      true,
+	"push":      true,
+	"ls-remote": true,
+}
+
+// the operation context is shared by every command of the current operation so cancelling it stops all of them,
+// including those of repos that haven't started yet
+var operationMutex sync.Mutex
+var operationCtx, cancelOperation = context.WithCancel(context.Background())
+
+// beginOperation gives the commands that follow a fresh context, the commands of a previously cancelled operation
+// stay cancelled
+func beginOperation(parent context.Context) {
+	operationMutex.Lock()
+	defer operationMutex.Unlock()
+	cancelOperation()
+	operationCtx, cancelOperation = context.WithCancel(parent)
+}
+
+// cancelGitOperation kills the in-flight commands of the current operation
+func cancelGitOperation() {
+	operationMutex.Lock()
+	defer operationMutex.Unlock()
+	cancelOperation()
+}
+
+func operationContext() context.Context {
+	operationMutex.Lock()
+	defer operationMutex.Unlock()
+	return operationCtx
+}
+
+func operationCancelled() bool {
+	return operationContext().Err() != nil
+}
+
+func gitTimeout(args []string) time.Duration {
+	if len(args) != 0 && networkGitCommands[args[0]] {
+		return time.Duration(config.GitNetworkTimeoutSeconds) * time.Second
+	}
+	return time.Duration(config.GitTimeoutSeconds) * time.Second
+}
+
+// newGitContext ends once the command runs past its timeout or the operation is cancelled
+func newGitContext(args []string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(operationContext(), gitTimeout(args))
+}
+
+// newGitCommand builds a git command that is killed when ctx ends. It never prompts, missing credentials fail the
+// command straight away instead of hanging on a prompt nobody can see.
+func newGitCommand(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" && os.Getenv("GIT_SSH") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	// ssh may keep the output pipes open after git is killed, this stops Wait from blocking on it
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
+
+// gitContextError explains why a command was killed, ctx must have ended
+func gitContextError(ctx context.Context, args []string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", gitTimeout(args))
+	}
+	return fmt.Errorf("cancelled")
+}
+
+// runGitCombinedOutput runs git and returns its untrimmed combined output
+func runGitCombinedOutput(dir string, args ...string) ([]byte, error) {
+	ctx, cancel := newGitContext(args)
+	defer cancel()
+	start := time.Now()
+	cmd := newGitCommand(ctx, dir, args...)
+	output, err := cmd.CombinedOutput()
+	logGitCommand(dir, args, start, cmd, output, err)
+	if err != nil && ctx.Err() != nil {
+		return output, gitContextError(ctx, args)
+	}
+	if err != nil {
+		return output, classifyGitError(string(output), err)
+	}
+	return output, nil
+}
+
+// runGitCommand runs git in the given directory and returns the combined output with surrounding whitespace trimmed
+func runGitCommand(dir string, args ...string) (string, error) {
+	output, err := runGitCombinedOutput(dir, args...)
+	if err != nil {
+		commandString := "git " + strings.Join(args, " ")
+		return string(output), fmt.Errorf("error, when running command: %s at directory: %s. Output: %s, Error: %w", commandString, dir, output, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func getBareRepoDir(r repo) string {
+	return reposDirectory + r.getRepoDirectoryName()
+}
+
+// getTrunkBranch returns the trunk configured for the repo, falling back to the default branch of the clone
+func getTrunkBranch(r repo) (string, error) {
+	if r.TrunkBranch != "" {
+		return r.TrunkBranch, nil
+	}
+	// HEAD of a bare clone points at the default branch of the remote
+	trunk, err := runGitCommand(getBareRepoDir(r), "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("error, when detecting trunk branch for %s. Error: %w", r.Title(), err)
+	}
+	return trunk, nil
+}
+
+// fetchLocalBranches returns the names of every local branch of the repo
+func fetchLocalBranches(r repo) ([]string, error) {
+	output, err := runGitCommand(getBareRepoDir(r), "for-each-ref", "--format=%(refname:short)", "refs/heads")
+	if err != nil {
+		return nil, fmt.Errorf("error, when listing branches for %s. Error: %w", r.Title(), err)
+	}
+	if output == "" {
+		return []string{}, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// fetchWorktreeBranches returns the worktree paths of the repo keyed by the branch they have checked out
+func fetchWorktreeBranches(r repo) (map[string]string, error) {
+	output, err := runGitCommand(getBareRepoDir(r), "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("error, when listing worktrees for %s. Error: %w", r.Title(), err)
+	}
+	result := make(map[string]string)
+	var worktreeDir string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "worktree ") {
+			worktreeDir = strings.TrimPrefix(line, "worktree ")
+		} else if strings.HasPrefix(line, "branch refs/heads/") {
+			result[strings.TrimPrefix(line, "branch refs/heads/")] = worktreeDir
+		}
+	}
+	return result, nil
+}
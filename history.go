@@ -0,0 +1,221 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// operationLogKind separates what the user did from the git commands it took
+type operationLogKind string
+
+const (
+	operationLogEvent operationLogKind = "event"
+	operationLogGit   operationLogKind = "git"
+)
+
+// operationLogOutputLimit keeps commands with huge output, e.g., a diff of a large change, from bloating the database
+const operationLogOutputLimit = 64 * 1024
+
+// operationLogEntry is one row of the durable history, an event for the user facing actions or a git command
+type operationLogEntry struct {
+	Id        int64            `json:"id"`
+	CreatedAt string           `json:"createdAt"`
+	Kind      operationLogKind `json:"kind"`
+	Action    string           `json:"action"`
+	// Subject is the repo url or effort name of an event and the directory of a git command
+	Subject    string `json:"subject"`
+	Command    string `json:"command,omitempty"`
+	ExitCode   int    `json:"exitCode"`
+	DurationMs int64  `json:"durationMs"`
+	// Output is what the git command printed or, for an event, why it failed
+	Output string `json:"output,omitempty"`
+}
+
+func (e operationLogEntry) failed() bool {
+	return e.ExitCode != 0
+}
+
+// operationLogFilter narrows down the history, the zero value matches everything
+type operationLogFilter struct {
+	Kind       operationLogKind
+	FailedOnly bool
+	// Text is matched against the action, subject, command and output
+	Text  string
+	Limit int
+}
+
+func insertOperationLog(entry operationLogEntry) {
+	// tests run without a database
+	if database == nil {
+		return
+	}
+	if len(entry.Output) > operationLogOutputLimit {
+		entry.Output = entry.Output[:operationLogOutputLimit] + "\n... output truncated"
+	}
+	_, err := database.Exec(
+		`INSERT INTO operation_log (created_at, kind, action, subject, command, exit_code, duration_ms, output)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.CreatedAt,
+		entry.Kind,
+		entry.Action,
+		entry.Subject,
+		entry.Command,
+		entry.ExitCode,
+		entry.DurationMs,
+		entry.Output,
+	)
+	if err != nil {
+		// the history is for debugging so failing to record it must not fail what is being recorded
+		log.Printf("error, when recording %s %s in the operation log: %v", entry.Kind, entry.Action, err)
+	}
+}
+
+// logEvent records a user facing action once it has finished, err is nil when it succeeded
+func logEvent(action string, subject string, start time.Time, err error) {
+	entry := operationLogEntry{
+		CreatedAt:  start.UTC().Format(time.RFC3339Nano),
+		Kind:       operationLogEvent,
+		Action:     action,
+		Subject:    subject,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.ExitCode = 1
+		entry.Output = err.Error()
+	}
+	insertOperationLog(entry)
+}
+
+// logGitCommand records a git command that has finished running, cmd is used for the exit code
+func logGitCommand(dir string, args []string, start time.Time, cmd *exec.Cmd, output []byte, err error) {
+	entry := operationLogEntry{
+		CreatedAt:  start.UTC().Format(time.RFC3339Nano),
+		Kind:       operationLogGit,
+		Subject:    dir,
+		Command:    "git " + strings.Join(args, " "),
+		DurationMs: time.Since(start).Milliseconds(),
+		Output:     string(output),
+	}
+	if len(args) != 0 {
+		entry.Action = args[0]
+	}
+	if cmd.ProcessState != nil {
+		entry.ExitCode = cmd.ProcessState.ExitCode()
+	} else if err != nil {
+		// the command never started
+		entry.ExitCode = -1
+	}
+	if err != nil && entry.Output == "" {
+		entry.Output = err.Error()
+	}
+	insertOperationLog(entry)
+}
+
+func fetchOperationLog(filter operationLogFilter) ([]operationLogEntry, error) {
+	var conditions []string
+	var args []any
+	if filter.Kind != "" {
+		conditions = append(conditions, "kind = ?")
+		args = append(args, filter.Kind)
+	}
+	if filter.FailedOnly {
+		conditions = append(conditions, "exit_code != 0")
+	}
+	if filter.Text != "" {
+		conditions = append(conditions, "(action LIKE ? OR subject LIKE ? OR command LIKE ? OR output LIKE ?)")
+		like := "%" + filter.Text + "%"
+		args = append(args, like, like, like, like)
+	}
+	theStatement := `SELECT id, created_at, kind, action, COALESCE(subject, ''), COALESCE(command, ''), COALESCE(exit_code, 0),
+			COALESCE(duration_ms, 0), COALESCE(output, '')
+		FROM operation_log`
+	if len(conditions) != 0 {
+		theStatement += "\n\t\tWHERE " + strings.Join(conditions, " AND ")
+	}
+	theStatement += "\n\t\tORDER BY id DESC"
+	if filter.Limit > 0 {
+		theStatement += "\n\t\tLIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := database.Query(theStatement, args...)
+	defer func(rows *sql.Rows) {
+		if rows != nil {
+			closeRowsError := rows.Close()
+			if closeRowsError != nil {
+				log.Printf("error, when attempting to close database rows: %v", closeRowsError)
+			}
+		}
+	}(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error, when attempting to retrieve the operation log. Error: %w", err)
+	}
+
+	entries := []operationLogEntry{}
+	for rows.Next() {
+		var e operationLogEntry
+		err = rows.Scan(
+			&e.Id,
+			&e.CreatedAt,
+			&e.Kind,
+			&e.Action,
+			&e.Subject,
+			&e.Command,
+			&e.ExitCode,
+			&e.DurationMs,
+			&e.Output,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error, when scanning operation log rows. Error: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error, when iterating through operation log rows. Error: %w", err)
+	}
+	return entries, nil
+}
+
+// pruneOperationLog drops the history older than operationLogRetentionDays
+func pruneOperationLog() error {
+	cutoff := time.Now().UTC().AddDate(0, 0, -config.OperationLogRetentionDays).Format(time.RFC3339Nano)
+	_, err := database.Exec(`DELETE FROM operation_log WHERE created_at < ?`, cutoff)
+	if err != nil {
+		return fmt.Errorf("error, when deleting old entries for pruneOperationLog(). Error: %w", err)
+	}
+	return nil
+}
+
+func exportOperationLog(w io.Writer, entries []operationLogEntry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(entries)
+	if err != nil {
+		return fmt.Errorf("error, when encoding operation log for exportOperationLog(). Error: %w", err)
+	}
+	return nil
+}
+
+func formatOperationLogEntry(e operationLogEntry) string {
+	createdAt := e.CreatedAt
+	parsed, err := time.Parse(time.RFC3339Nano, e.CreatedAt)
+	if err == nil {
+		createdAt = parsed.Local().Format("2006-01-02 15:04:05")
+	}
+	outcome := "ok"
+	if e.failed() {
+		outcome = fmt.Sprintf("exit %d", e.ExitCode)
+	}
+	what := e.Action + " " + e.Subject
+	if e.Kind == operationLogGit {
+		what = e.Command + " in " + e.Subject
+	}
+	return fmt.Sprintf("%s  %-5s  %6dms  %-7s  %s", createdAt, e.Kind, e.DurationMs, outcome, what)
+}
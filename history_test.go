@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_fetchOperationLog(t *testing.T) {
+	openTestDatabase(t)
+
+	logEvent("create effort", "feature", time.Now(), nil)
+	logEvent("delete repo", "git@example.com:team/alpha.git", time.Now(), errors.New("repo is in use"))
+	args := []string{"rev-parse", "--verify", "no-such-branch"}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = t.TempDir()
+	output, err := cmd.CombinedOutput()
+	logGitCommand(cmd.Dir, args, time.Now(), cmd, output, err)
+
+	tests := []struct {
+		name    string
+		filter  operationLogFilter
+		actions []string
+	}{
+		{name: "everything newest first", filter: operationLogFilter{}, actions: []string{"rev-parse", "delete repo", "create effort"}},
+		{name: "events", filter: operationLogFilter{Kind: operationLogEvent}, actions: []string{"delete repo", "create effort"}},
+		{name: "git commands", filter: operationLogFilter{Kind: operationLogGit}, actions: []string{"rev-parse"}},
+		{name: "failures", filter: operationLogFilter{FailedOnly: true}, actions: []string{"rev-parse", "delete repo"}},
+		{name: "text in the output", filter: operationLogFilter{Text: "in use"}, actions: []string{"delete repo"}},
+		{name: "limit", filter: operationLogFilter{Limit: 1}, actions: []string{"rev-parse"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := fetchOperationLog(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actions []string
+			for _, e := range entries {
+				actions = append(actions, e.Action)
+			}
+			if strings.Join(actions, ",") != strings.Join(tt.actions, ",") {
+				t.Errorf("got %v, but wanted %v", actions, tt.actions)
+			}
+		})
+	}
+
+	entries, err := fetchOperationLog(operationLogFilter{Kind: operationLogGit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = exportOperationLog(&buf, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported []operationLogEntry
+	err = json.Unmarshal(buf.Bytes(), &exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 1 || exported[0].ExitCode == 0 || exported[0].Command != "git rev-parse --verify no-such-branch" || exported[0].Output == "" {
+		t.Errorf("got %+v, but wanted the failed rev-parse with its exit code and output", exported)
+	}
+}
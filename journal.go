@@ -0,0 +1,716 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// operationKind is a multi step operation that is journaled so it can be resumed or rolled back after a crash
+type operationKind string
+
+const (
+	operationKindApply        operationKind = "apply repo selection"
+	operationKindDeleteEffort operationKind = "delete effort"
+	operationKindAdopt        operationKind = "adopt branch"
+)
+
+type operationStatus string
+
+const (
+	// operationRunning is also what an operation is left as when the process dies partway through
+	operationRunning    operationStatus = "running"
+	operationCompleted  operationStatus = "completed"
+	operationFailed     operationStatus = "failed"
+	operationRolledBack operationStatus = "rolled back"
+	operationAbandoned  operationStatus = "abandoned"
+)
+
+type journalAction string
+
+const (
+	journalActionCreateWorktree      journalAction = "create worktree"
+	journalActionDeleteWorktree      journalAction = "delete worktree"
+	journalActionForceDeleteWorktree journalAction = "force delete worktree"
+	journalActionPersistSelection    journalAction = "save repo selection"
+	journalActionDeleteEffortRecord  journalAction = "delete effort record"
+	journalActionAdoptWorktree       journalAction = "adopt worktree"
+)
+
+type stepStatus string
+
+const (
+	stepPlanned   stepStatus = "planned"
+	stepStarted   stepStatus = "started"
+	stepCompleted stepStatus = "completed"
+	stepFailed    stepStatus = "failed"
+)
+
+type journalStep struct {
+	Id     int64
+	Action journalAction
+	// theRepo is empty for the steps that aren't about a single repo
+	theRepo repo
+	Status  stepStatus
+	Detail  string
+	// Changes is whether the step had anything to do when it first started, e.g., a worktree that was already gone
+	// didn't need deleting. Rolling back skips the steps that changed nothing.
+	Changes bool
+	// SourceDir is where the worktree an adopt step moves into the effort directory was, empty when it gets a new one
+	SourceDir string
+}
+
+// operationJournal is the plan of an operation along with how far it got, every step is written to the database
+// before and after it runs
+type operationJournal struct {
+	Id        int64
+	Kind      operationKind
+	theEffort effort
+	Force     bool
+	// RecoveryDir is where a force delete saved the unmerged work, rolling back restores the branches from it
+	RecoveryDir string
+	Pid         int
+	Status      operationStatus
+	StartedAt   string
+	Steps       []journalStep
+}
+
+func (j operationJournal) Title() string {
+	title := fmt.Sprintf("#%d %s %s", j.Id, j.Kind, j.theEffort.Name)
+	if j.Force {
+		title += " (force)"
+	}
+	if j.Status == operationFailed {
+		title += " (failed)"
+	}
+	return title
+}
+
+func (j operationJournal) completedSteps() int {
+	count := 0
+	for _, step := range j.Steps {
+		if step.Status == stepCompleted {
+			count++
+		}
+	}
+	return count
+}
+
+// beginJournal records the plan before any of it runs so a crash at any point leaves a record of what was intended
+func beginJournal(kind operationKind, theEffort effort, force bool, recoveryDir string, steps []journalStep) (*operationJournal, error) {
+	j := &operationJournal{
+		Kind:        kind,
+		theEffort:   theEffort,
+		Force:       force,
+		RecoveryDir: recoveryDir,
+		Pid:         os.Getpid(),
+		Status:      operationRunning,
+		StartedAt:   time.Now().UTC().Format(time.RFC3339),
+		Steps:       steps,
+	}
+	tx, err := database.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error, when starting transaction for beginJournal(). Error: %w", err)
+	}
+	defer tx.Rollback()
+	// a new operation on the effort supersedes the failed ones before it, their steps are about an older state
+	_, err = tx.Exec(
+		`UPDATE operation SET status = ?, finished_at = ? WHERE effort_id = ? AND status = ?`,
+		operationAbandoned,
+		j.StartedAt,
+		theEffort.Id,
+		operationFailed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error, when abandoning failed operations for beginJournal(). Error: %w", err)
+	}
+	result, err := tx.Exec(
+		`INSERT INTO operation (kind, effort_id, effort_name, branch_name, force, recovery_dir, pid, status, started_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		j.Kind,
+		theEffort.Id,
+		theEffort.Name,
+		theEffort.BranchName,
+		force,
+		recoveryDir,
+		j.Pid,
+		operationRunning,
+		j.StartedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error, when inserting operation for beginJournal(). Error: %w", err)
+	}
+	j.Id, err = result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error, when reading operation id for beginJournal(). Error: %w", err)
+	}
+	for i := range j.Steps {
+		j.Steps[i].Status = stepPlanned
+		result, err = tx.Exec(
+			`INSERT INTO operation_step (operation_id, position, action, repo_id, repo_url, status, source_dir, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			j.Id,
+			i,
+			j.Steps[i].Action,
+			j.Steps[i].theRepo.Id,
+			j.Steps[i].theRepo.Url,
+			stepPlanned,
+			j.Steps[i].SourceDir,
+			j.StartedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error, when inserting operation step for beginJournal(). Error: %w", err)
+		}
+		j.Steps[i].Id, err = result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("error, when reading operation step id for beginJournal(). Error: %w", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error, when committing transaction for beginJournal(). Error: %w", err)
+	}
+	return j, nil
+}
+
+func (j *operationJournal) setStepStatus(i int, status stepStatus, detail string) error {
+	j.Steps[i].Status = status
+	j.Steps[i].Detail = detail
+	_, err := database.Exec(
+		`UPDATE operation_step SET status = ?, detail = ?, changes = ?, updated_at = ? WHERE id = ?`,
+		status,
+		detail,
+		j.Steps[i].Changes,
+		time.Now().UTC().Format(time.RFC3339),
+		j.Steps[i].Id,
+	)
+	if err != nil {
+		return fmt.Errorf("error, when updating operation step for setStepStatus(). Error: %w", err)
+	}
+	return nil
+}
+
+// runStep records the step as started, runs it then records how it went. changes is only recorded the first time the
+// step starts, a step resumed after a crash may find its own work partly done.
+func (j *operationJournal) runStep(i int, changes bool, step func() (string, error)) (string, error) {
+	if j.Steps[i].Status == stepPlanned {
+		j.Steps[i].Changes = changes
+	}
+	err := j.setStepStatus(i, stepStarted, "")
+	if err != nil {
+		return "", fmt.Errorf("error, when setStepStatus() for runStep(). Error: %w", err)
+	}
+	detail, stepErr := step()
+	if stepErr != nil {
+		err = j.setStepStatus(i, stepFailed, stepErr.Error())
+		if err != nil {
+			log.Printf("error, when recording failed step of operation %d: %v", j.Id, err)
+		}
+		return "", stepErr
+	}
+	err = j.setStepStatus(i, stepCompleted, detail)
+	if err != nil {
+		return "", fmt.Errorf("error, when setStepStatus() for runStep(). Error: %w", err)
+	}
+	return detail, nil
+}
+
+func (j *operationJournal) finish(status operationStatus) error {
+	_, err := database.Exec(
+		`UPDATE operation SET status = ?, finished_at = ? WHERE id = ?`,
+		status,
+		time.Now().UTC().Format(time.RFC3339),
+		j.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("error, when updating operation for finish(). Error: %w", err)
+	}
+	j.Status = status
+	return nil
+}
+
+// finishAfter closes the journal according to err, which is returned as is so it can wrap the result of running the
+// steps
+func (j *operationJournal) finishAfter(results []repoResult, err error) ([]repoResult, error) {
+	status := operationCompleted
+	if err != nil {
+		status = operationFailed
+	}
+	finishErr := j.finish(status)
+	if finishErr != nil && err == nil {
+		return results, fmt.Errorf("error, when finish() for finishAfter(). Error: %w", finishErr)
+	}
+	return results, err
+}
+
+// runJournalSteps runs every step that hasn't completed yet in order. Consecutive repo steps run together through the
+// worker pool and a failure stops the steps after them from running.
+func runJournalSteps(j *operationJournal) ([]repoResult, error) {
+	var results []repoResult
+	for i := 0; i < len(j.Steps); {
+		if j.Steps[i].theRepo.Url == "" {
+			if j.Steps[i].Status != stepCompleted {
+				step := j.Steps[i]
+				_, err := j.runStep(i, true, func() (string, error) {
+					return executeJournalStep(j, step)
+				})
+				if err != nil {
+					return results, fmt.Errorf("error, when running step %s of operation %d. Error: %w", step.Action, j.Id, err)
+				}
+			}
+			i++
+			continue
+		}
+
+		var batch []int
+		for ; i < len(j.Steps) && j.Steps[i].theRepo.Url != ""; i++ {
+			if j.Steps[i].Status != stepCompleted {
+				batch = append(batch, i)
+			}
+		}
+		batchRepos := make([]repo, len(batch))
+		stepByRepo := make(map[string]int, len(batch))
+		for k, index := range batch {
+			batchRepos[k] = j.Steps[index].theRepo
+			stepByRepo[j.Steps[index].theRepo.Url] = index
+		}
+		batchResults := runRepoTasks(batchRepos, func(r repo) (string, error) {
+			index := stepByRepo[r.Url]
+			step := j.Steps[index]
+			changes, err := journalStepChanges(j, step)
+			if err != nil {
+				return "", fmt.Errorf("error, when journalStepChanges() for runJournalSteps(). Error: %w", err)
+			}
+			return j.runStep(index, changes, func() (string, error) {
+				return executeJournalStep(j, step)
+			})
+		})
+		results = append(results, batchResults...)
+		err := repoResultsError(batchResults)
+		if err != nil {
+			return results, fmt.Errorf("error, when running repo steps of operation %d. Error: %w", j.Id, err)
+		}
+	}
+	return results, nil
+}
+
+// executeJournalStep runs the step, given as it was before being marked started so a step that an interrupted run
+// had already started can be told apart from one that never ran
+func executeJournalStep(j *operationJournal, step journalStep) (string, error) {
+	switch step.Action {
+	case journalActionCreateWorktree:
+		return "", createWorktree(j.theEffort, step.theRepo)
+	case journalActionDeleteWorktree:
+		if step.Status != stepPlanned {
+			return finishDeletingWorktree(j.theEffort, step.theRepo)
+		}
+		return deleteWorktree(j.theEffort, step.theRepo)
+	case journalActionForceDeleteWorktree:
+		return forceDeleteWorktree(j.theEffort, step.theRepo)
+	case journalActionPersistSelection:
+		selection, err := j.repoSelection()
+		if err != nil {
+			return "", fmt.Errorf("error, when repoSelection() for executeJournalStep(). Error: %w", err)
+		}
+		return "", persistRepoSelection(j.theEffort.Id, selection)
+	case journalActionDeleteEffortRecord:
+		return "", deleteEffortRecord(j.theEffort)
+	case journalActionAdoptWorktree:
+		return "", adoptWorktree(j.theEffort, step.theRepo, step.SourceDir)
+	default:
+		return "", fmt.Errorf("error, unknown journal action: %s", step.Action)
+	}
+}
+
+// journalStepChanges tells whether the repo step has anything to do given the worktree as it is now
+func journalStepChanges(j *operationJournal, step journalStep) (bool, error) {
+	worktreeExists, err := checkDirectoryExists(getWorktreeDir(j.theEffort, step.theRepo))
+	if err != nil {
+		return false, fmt.Errorf("error, when checkDirectoryExists() for journalStepChanges(). Error: %w", err)
+	}
+	switch step.Action {
+	case journalActionCreateWorktree, journalActionAdoptWorktree:
+		return !worktreeExists, nil
+	case journalActionForceDeleteWorktree:
+		if worktreeExists {
+			return true, nil
+		}
+		// the branches and stashed work go even when the worktree is already gone
+		branchExists, err := doesBranchExist(j.theEffort.BranchName, getBareRepoDir(step.theRepo))
+		if err != nil {
+			return false, fmt.Errorf("error, when doesBranchExist() for journalStepChanges(). Error: %w", err)
+		}
+		if branchExists {
+			return true, nil
+		}
+		_, err = runGitCommand(getBareRepoDir(step.theRepo), "rev-parse", "--verify", "--quiet", getUncommittedWorkRef(j.theEffort.BranchName))
+		return err == nil, nil
+	default:
+		return worktreeExists, nil
+	}
+}
+
+// repoSelection is the selection the effort ends up with, the repos the steps create worktrees for are added to the
+// saved selection and the ones they delete worktrees for are taken out of it
+func (j *operationJournal) repoSelection() ([]repo, error) {
+	previous, err := fetchReposForEffort(j.theEffort.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposForEffort() for repoSelection(). Error: %w", err)
+	}
+	change := make(map[int64]journalAction)
+	for _, step := range j.Steps {
+		if step.theRepo.Url != "" {
+			change[step.theRepo.Id] = step.Action
+		}
+	}
+	var selection []repo
+	for _, r := range previous {
+		if _, ok := change[r.Id]; !ok {
+			selection = append(selection, r)
+		}
+	}
+	for _, step := range j.Steps {
+		if step.Action == journalActionCreateWorktree || step.Action == journalActionAdoptWorktree {
+			selection = append(selection, step.theRepo)
+		}
+	}
+	return selection, nil
+}
+
+// resumeOperation runs the steps an interrupted operation didn't complete, a step that was started may have been
+// partly done which is fine since every step picks up from whatever state it finds. The operation stays unfinished if
+// the resume fails so it can still be rolled back.
+func resumeOperation(j *operationJournal) ([]repoResult, error) {
+	results, err := runJournalSteps(j)
+	if err != nil {
+		return results, fmt.Errorf("error, when runJournalSteps() for resumeOperation(). Error: %w", err)
+	}
+	err = j.finish(operationCompleted)
+	if err != nil {
+		return results, fmt.Errorf("error, when finish() for resumeOperation(). Error: %w", err)
+	}
+	return results, nil
+}
+
+// rollbackOperation undoes every step that was started, latest first, so the effort is left as it was before the
+// operation began, an adopted effort goes altogether since it didn't exist before. Deleting the effort record is the last step and can't be undone, once it completed the operation is
+// as good as finished.
+func rollbackOperation(j *operationJournal) ([]repoResult, error) {
+	var results []repoResult
+	for i := len(j.Steps) - 1; i >= 0; i-- {
+		step := j.Steps[i]
+		if step.Status == stepPlanned {
+			continue
+		}
+		if step.Action == journalActionDeleteEffortRecord && step.Status == stepCompleted {
+			return nil, fmt.Errorf("effort %s has already been deleted, operation %d can only be resumed", j.theEffort.Name, j.Id)
+		}
+		if step.theRepo.Url == "" || !step.Changes {
+			continue
+		}
+		result := repoResult{theRepo: step.theRepo, Outcome: repoOutcomeSucceeded}
+		detail, err := undoJournalStep(j, step)
+		if err != nil {
+			result.Outcome = repoOutcomeFailed
+			result.Err = err
+			detail = err.Error()
+		}
+		result.Detail = fmt.Sprintf("undid %s: %s", step.Action, detail)
+		results = append(results, result)
+	}
+	err := repoResultsError(results)
+	if err != nil {
+		return results, fmt.Errorf("error, when undoing steps for rollbackOperation(). Error: %w", err)
+	}
+	if j.Kind == operationKindApply {
+		// the selection is saved as whatever worktrees exist now that the steps have been undone
+		err = persistExistingWorktrees(j)
+		if err != nil {
+			return results, fmt.Errorf("error, when persistExistingWorktrees() for rollbackOperation(). Error: %w", err)
+		}
+	}
+	if j.Kind == operationKindAdopt {
+		err = deleteEffortRecord(j.theEffort)
+		if err != nil {
+			return results, fmt.Errorf("error, when deleteEffortRecord() for rollbackOperation(). Error: %w", err)
+		}
+	}
+	err = j.finish(operationRolledBack)
+	if err != nil {
+		return results, fmt.Errorf("error, when finish() for rollbackOperation(). Error: %w", err)
+	}
+	return results, nil
+}
+
+func undoJournalStep(j *operationJournal, step journalStep) (string, error) {
+	switch step.Action {
+	case journalActionCreateWorktree:
+		return deleteWorktree(j.theEffort, step.theRepo)
+	case journalActionDeleteWorktree:
+		return "recreated worktree", createWorktree(j.theEffort, step.theRepo)
+	case journalActionForceDeleteWorktree:
+		detail, err := restoreBranchFromBundle(j, step.theRepo)
+		if err != nil {
+			return "", fmt.Errorf("error, when restoreBranchFromBundle() for undoJournalStep(). Error: %w", err)
+		}
+		// a repo that was only force deleted for the work it preserved gets that work back without a worktree
+		selected, err := fetchSelectedReposForEffort(j.theEffort.Id)
+		if err != nil {
+			return "", fmt.Errorf("error, when fetchSelectedReposForEffort() for undoJournalStep(). Error: %w", err)
+		}
+		if !selected[step.theRepo.Id] {
+			return detail, nil
+		}
+		return "recreated worktree, " + detail, createWorktree(j.theEffort, step.theRepo)
+	case journalActionAdoptWorktree:
+		return unadoptWorktree(j.theEffort, step.theRepo, step.SourceDir)
+	default:
+		return "", fmt.Errorf("error, %s can't be undone", step.Action)
+	}
+}
+
+// restoreBranchFromBundle gets the effort branch back from the bundle saveUnmergedWork wrote before force deleting
+func restoreBranchFromBundle(j *operationJournal, r repo) (string, error) {
+	bundle := j.RecoveryDir + r.Title() + ".bundle"
+	_, err := os.Stat(bundle)
+	if os.IsNotExist(err) {
+		return "there was no unmerged work to restore", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error, when checking for bundle for restoreBranchFromBundle(). Error: %w", err)
+	}
+	heads, err := runGitCommand(getBareRepoDir(r), "bundle", "list-heads", bundle)
+	if err != nil {
+		return "", fmt.Errorf("error, when listing bundle refs for restoreBranchFromBundle(). Error: %w", err)
+	}
+	// a repo that was no longer in the effort may only have had stashed work saved
+	var refspecs []string
+	for _, ref := range []string{"refs/heads/" + j.theEffort.BranchName, getUncommittedWorkRef(j.theEffort.BranchName)} {
+		if strings.Contains(heads+"\n", " "+ref+"\n") {
+			refspecs = append(refspecs, fmt.Sprintf("+%s:%s", ref, ref))
+		}
+	}
+	if len(refspecs) == 0 {
+		return "the bundle only had what had been pushed", nil
+	}
+	_, err = runGitCommand(getBareRepoDir(r), append([]string{"fetch", bundle}, refspecs...)...)
+	if err != nil {
+		return "", fmt.Errorf("error, when fetching branch from bundle for restoreBranchFromBundle(). Error: %w", err)
+	}
+	return "restored the branch from " + bundle, nil
+}
+
+// persistExistingWorktrees saves the selection as the repos of the saved selection and of the steps that have a
+// worktree, the repos the operation didn't touch keep whatever they had
+func persistExistingWorktrees(j *operationJournal) error {
+	candidates, err := fetchReposForEffort(j.theEffort.Id)
+	if err != nil {
+		return fmt.Errorf("error, when fetchReposForEffort() for persistExistingWorktrees(). Error: %w", err)
+	}
+	for _, step := range j.Steps {
+		if step.theRepo.Url != "" {
+			candidates = append(candidates, step.theRepo)
+		}
+	}
+	var existing []repo
+	seen := make(map[int64]bool)
+	for _, r := range candidates {
+		if seen[r.Id] {
+			continue
+		}
+		seen[r.Id] = true
+		exists, err := checkDirectoryExists(getWorktreeDir(j.theEffort, r))
+		if err != nil {
+			return fmt.Errorf("error, when checkDirectoryExists() for persistExistingWorktrees(). Error: %w", err)
+		}
+		if exists {
+			existing = append(existing, r)
+		}
+	}
+	if len(existing) == 0 {
+		_, err := database.Exec(`DELETE FROM effort_repo WHERE effort_id = ?`, j.theEffort.Id)
+		if err != nil {
+			return fmt.Errorf("error, when clearing repo selection for persistExistingWorktrees(). Error: %w", err)
+		}
+		return nil
+	}
+	return persistRepoSelection(j.theEffort.Id, existing)
+}
+
+// abandonOperation stops offering to recover the operation and leaves everything as it is
+func abandonOperation(j *operationJournal) error {
+	err := j.finish(operationAbandoned)
+	if err != nil {
+		return fmt.Errorf("error, when finish() for abandonOperation(). Error: %w", err)
+	}
+	return nil
+}
+
+// fetchUnfinishedOperations returns the operations still marked running whose process is gone, those were
+// interrupted by a crash, a kill or the machine going down, along with the failed operations that changed something
+// before they stopped
+func fetchUnfinishedOperations() ([]*operationJournal, error) {
+	rows, err := database.Query(
+		`SELECT id, kind, effort_id, effort_name, branch_name, force, COALESCE(recovery_dir, ''), pid, status, started_at
+		FROM operation o
+		WHERE status = ?
+			OR (status = ? AND EXISTS (
+				SELECT 1
+				FROM operation_step s
+				WHERE s.operation_id = o.id AND s.status = ? AND s.changes
+			))
+		ORDER BY id`,
+		operationRunning,
+		operationFailed,
+		stepCompleted,
+	)
+	defer func(rows *sql.Rows) {
+		if rows != nil {
+			closeRowsError := rows.Close()
+			if closeRowsError != nil {
+				log.Printf("error, when attempting to close database rows: %v", closeRowsError)
+			}
+		}
+	}(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error, when attempting to retrieve operations. Error: %w", err)
+	}
+
+	var journals []*operationJournal
+	for rows.Next() {
+		j := &operationJournal{}
+		err = rows.Scan(
+			&j.Id,
+			&j.Kind,
+			&j.theEffort.Id,
+			&j.theEffort.Name,
+			&j.theEffort.BranchName,
+			&j.Force,
+			&j.RecoveryDir,
+			&j.Pid,
+			&j.Status,
+			&j.StartedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error, when scanning operation rows. Error: %w", err)
+		}
+		if j.Status == operationRunning && isProcessRunning(j.Pid) {
+			continue
+		}
+		journals = append(journals, j)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error, when iterating through operation rows. Error: %w", err)
+	}
+
+	for _, j := range journals {
+		j.Steps, err = fetchJournalSteps(j.Id)
+		if err != nil {
+			return nil, fmt.Errorf("error, when fetchJournalSteps() for fetchUnfinishedOperations(). Error: %w", err)
+		}
+	}
+	return journals, nil
+}
+
+func fetchJournalSteps(operationId int64) ([]journalStep, error) {
+	rows, err := database.Query(
+		`SELECT id, action, COALESCE(repo_id, 0), COALESCE(repo_url, ''), status, COALESCE(detail, ''), changes,
+			COALESCE(source_dir, '')
+		FROM operation_step
+		WHERE operation_id = ?
+		ORDER BY position`,
+		operationId,
+	)
+	defer func(rows *sql.Rows) {
+		if rows != nil {
+			closeRowsError := rows.Close()
+			if closeRowsError != nil {
+				log.Printf("error, when attempting to close database rows: %v", closeRowsError)
+			}
+		}
+	}(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error, when attempting to retrieve operation steps. Error: %w", err)
+	}
+
+	var steps []journalStep
+	repoIds := make(map[int64]bool)
+	for rows.Next() {
+		var step journalStep
+		err = rows.Scan(
+			&step.Id,
+			&step.Action,
+			&step.theRepo.Id,
+			&step.theRepo.Url,
+			&step.Status,
+			&step.Detail,
+			&step.Changes,
+			&step.SourceDir,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error, when scanning operation step rows. Error: %w", err)
+		}
+		if step.theRepo.Id != 0 {
+			repoIds[step.theRepo.Id] = true
+		}
+		steps = append(steps, step)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error, when iterating through operation step rows. Error: %w", err)
+	}
+
+	// the repo rows have the trunk branch, the url recorded in the step is enough if the repo has since been deleted
+	if len(repoIds) != 0 {
+		repos, err := fetchReposForIds(repoIds)
+		if err != nil {
+			return nil, fmt.Errorf("error, when fetchReposForIds() for fetchJournalSteps(). Error: %w", err)
+		}
+		for _, r := range repos {
+			for i := range steps {
+				if steps[i].theRepo.Id == r.Id {
+					steps[i].theRepo = r
+				}
+			}
+		}
+	}
+	return steps, nil
+}
+
+// isProcessRunning keeps an operation that another instance of the tool is still working on from being offered for
+// recovery
+func isProcessRunning(pid int) bool {
+	if pid == 0 {
+		return false
+	}
+	if pid == os.Getpid() {
+		return true
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+func formatJournal(j *operationJournal) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "%s, started %s, %d of %d steps completed\n", j.Title(), j.StartedAt, j.completedSteps(), len(j.Steps))
+	for _, step := range j.Steps {
+		target := ""
+		if step.theRepo.Url != "" {
+			target = " " + step.theRepo.Title()
+		}
+		fmt.Fprintf(&s, "    %-9s %s%s", step.Status, step.Action, target)
+		if step.Detail != "" {
+			fmt.Fprintf(&s, ": %s", step.Detail)
+		}
+		s.WriteString("\n")
+	}
+	return s.String()
+}
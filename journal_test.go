@@ -0,0 +1,276 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// openTestDatabase migrates a throwaway database, it is closed once the test is done and the database the package had
+// before is put back so tests don't depend on the order they run in. Backups of it go to a throwaway directory too.
+func openTestDatabase(t *testing.T) {
+	previousDatabase := database
+	previousDatabaseFile := databaseFile
+	previousBackupsDirectory := backupsDirectory
+	databaseFile = t.TempDir() + "/data"
+	backupsDirectory = t.TempDir() + "/"
+	err := openDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		database = previousDatabase
+		databaseFile = previousDatabaseFile
+		backupsDirectory = previousBackupsDirectory
+	})
+	err = ProcessSchemaChanges(databaseFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_fetchUnfinishedOperations(t *testing.T) {
+	openTestDatabase(t)
+
+	theEffort := effort{Id: 7, Name: "feature", BranchName: "ABC-1"}
+	alpha := repo{Id: 1, Url: "git@example.com:team/alpha.git"}
+	journal, err := beginJournal(operationKindApply, theEffort, false, "", []journalStep{
+		{Action: journalActionCreateWorktree, theRepo: alpha},
+		{Action: journalActionPersistSelection},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = journal.runStep(0, true, func() (string, error) { return "done", nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an operation of this process is still running, not unfinished
+	unfinished, err := fetchUnfinishedOperations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unfinished) != 0 {
+		t.Fatalf("got %d unfinished operations, but wanted none while this process is running it", len(unfinished))
+	}
+
+	// a pid of 0 stands in for a process that died partway through
+	_, err = database.Exec(`UPDATE operation SET pid = 0 WHERE id = ?`, journal.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unfinished, err = fetchUnfinishedOperations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unfinished) != 1 {
+		t.Fatalf("got %d unfinished operations, but wanted 1", len(unfinished))
+	}
+	got := unfinished[0]
+	if got.theEffort.Id != theEffort.Id || got.theEffort.BranchName != theEffort.BranchName || got.Kind != operationKindApply || len(got.Steps) != 2 {
+		t.Fatalf("got %+v, but wanted the journaled apply of %s", got, theEffort.Name)
+	}
+	if got.Steps[0].Status != stepCompleted || got.Steps[0].Detail != "done" || got.Steps[0].theRepo.Url != alpha.Url {
+		t.Errorf("got first step %+v, but wanted the completed alpha step", got.Steps[0])
+	}
+	if got.Steps[1].Status != stepPlanned || got.completedSteps() != 1 {
+		t.Errorf("got second step %+v, but wanted it still planned", got.Steps[1])
+	}
+
+	err = abandonOperation(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unfinished, err = fetchUnfinishedOperations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unfinished) != 0 {
+		t.Errorf("got %d unfinished operations after abandoning, but wanted none", len(unfinished))
+	}
+}
+
+func Test_planRepoSelectionSteps(t *testing.T) {
+	openTestDatabase(t)
+	previousEffortsDirectory := effortsDirectory
+	effortsDirectory = t.TempDir() + "/"
+	t.Cleanup(func() { effortsDirectory = previousEffortsDirectory })
+
+	theEffort := effort{Id: 1, Name: "feature", BranchName: "ABC-1"}
+	alpha := repo{Id: 1, Url: "git@example.com:team/alpha.git"}
+	beta := repo{Id: 2, Url: "git@example.com:team/beta.git"}
+	gamma := repo{Id: 3, Url: "git@example.com:team/gamma.git"}
+	delta := repo{Id: 4, Url: "git@example.com:team/delta.git"}
+	unrelated := repo{Id: 5, Url: "git@example.com:team/unrelated.git"}
+	_, err := database.Exec(`INSERT INTO effort (id, name, branch_name, description) VALUES (1, 'feature', 'ABC-1', '')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []repo{alpha, beta, gamma, delta, unrelated} {
+		_, err = database.Exec(`INSERT INTO repo (id, url, trunk_branch) VALUES (?, ?, 'main')`, r.Id, r.Url)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// alpha and beta were selected before, beta has since lost its worktree and delta has been deselected
+	for _, r := range []repo{alpha, beta, delta} {
+		_, err = database.Exec(`INSERT INTO effort_repo (effort_id, repo_id) VALUES (1, ?)`, r.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range []repo{alpha, delta} {
+		err = os.MkdirAll(getWorktreeDir(theEffort, r), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps, err := planRepoSelectionSteps(theEffort, []repo{alpha, beta, gamma}, []repo{delta, unrelated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, step := range steps {
+		got = append(got, fmt.Sprintf("%s %s", step.Action, step.theRepo.Title()))
+	}
+	want := []string{
+		"create worktree beta",
+		"create worktree gamma",
+		"delete worktree delta",
+		"save repo selection ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got steps %q, but wanted %q", got, want)
+	}
+
+	journal, err := beginJournal(operationKindApply, theEffort, false, "", steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selection, err := journal.repoSelection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var selected []string
+	for _, r := range selection {
+		selected = append(selected, r.Title())
+	}
+	sort.Strings(selected)
+	if !reflect.DeepEqual(selected, []string{"alpha", "beta", "gamma"}) {
+		t.Errorf("got selection %q, but wanted alpha, beta and gamma", selected)
+	}
+}
+
+func Test_rollbackOperation_skipsStepsThatChangedNothing(t *testing.T) {
+	openTestDatabase(t)
+	previousEffortsDirectory := effortsDirectory
+	effortsDirectory = t.TempDir() + "/"
+	t.Cleanup(func() { effortsDirectory = previousEffortsDirectory })
+
+	theEffort := effort{Id: 1, Name: "feature", BranchName: "ABC-1"}
+	alpha := repo{Id: 1, Url: "git@example.com:team/alpha.git"}
+	journal, err := beginJournal(operationKindDeleteEffort, theEffort, false, "", []journalStep{
+		{Action: journalActionDeleteWorktree, theRepo: alpha},
+		{Action: journalActionDeleteEffortRecord},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the worktree was already gone so there was nothing to delete, recreating it would make one that never existed
+	_, err = journal.runStep(0, false, func() (string, error) { return "", nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a resumed step keeps what it recorded the first time it started
+	_, err = journal.runStep(0, true, func() (string, error) { return "", nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := fetchJournalSteps(journal.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps[0].Changes {
+		t.Fatalf("got step %+v, but wanted it recorded as having changed nothing", steps[0])
+	}
+	journal.Steps = steps
+
+	results, err := rollbackOperation(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("got %d undone steps, but wanted none", len(results))
+	}
+}
+
+func Test_fetchUnfinishedOperations_failed(t *testing.T) {
+	openTestDatabase(t)
+
+	theEffort := effort{Id: 7, Name: "feature", BranchName: "ABC-1"}
+	alpha := repo{Id: 1, Url: "git@example.com:team/alpha.git"}
+	beta := repo{Id: 2, Url: "git@example.com:team/beta.git"}
+	fail := func(changes bool) *operationJournal {
+		t.Helper()
+		journal, err := beginJournal(operationKindDeleteEffort, theEffort, false, "", []journalStep{
+			{Action: journalActionDeleteWorktree, theRepo: alpha},
+			{Action: journalActionDeleteWorktree, theRepo: beta},
+			{Action: journalActionDeleteEffortRecord},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = journal.runStep(0, changes, func() (string, error) { return "deleted", nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = journal.runStep(1, true, func() (string, error) { return "", errors.New("not merged") })
+		if err == nil {
+			t.Fatal("got no error from the failing step")
+		}
+		err = journal.finish(operationFailed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return journal
+	}
+
+	// nothing was changed before the failure so there is nothing to recover
+	fail(false)
+	unfinished, err := fetchUnfinishedOperations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unfinished) != 0 {
+		t.Fatalf("got %d unfinished operations, but wanted none for a failure that changed nothing", len(unfinished))
+	}
+
+	// the process that failed is still running, which doesn't matter for a failed operation
+	partial := fail(true)
+	unfinished, err = fetchUnfinishedOperations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unfinished) != 1 || unfinished[0].Id != partial.Id || unfinished[0].Status != operationFailed {
+		t.Fatalf("got %v, but wanted the failed operation %d that deleted alpha", unfinished, partial.Id)
+	}
+
+	// starting over on the effort supersedes the failed operation
+	_, err = beginJournal(operationKindDeleteEffort, theEffort, false, "", []journalStep{{Action: journalActionDeleteEffortRecord}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unfinished, err = fetchUnfinishedOperations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unfinished) != 0 {
+		t.Errorf("got %d unfinished operations, but wanted the failed one superseded", len(unfinished))
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"embed"
 	"fmt"
 	"log"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -20,6 +22,9 @@ var databaseFiles embed.FS
 var dataDirectory string
 var reposDirectory string
 var effortsDirectory string
+var backupsDirectory string
+var recoveryDirectory string
+var databaseFile string
 
 var docStyle = lipgloss.NewStyle().
 	Bold(true).
@@ -34,14 +39,56 @@ type model struct {
 	deleteEffortTextInput           textinput.Model
 	deleteRepoTextInput             textinput.Model
 	listFilterTextInput             textinput.Model
+	commitMessageTextInput          textinput.Model
+	execCommandTextInput            textinput.Model
+	searchTextInput                 textinput.Model
+	historyFilterTextInput          textinput.Model
 	repos                           list.Model
 	efforts                         list.Model
 	effortRepoVisibleSelection      []repo
 	selectedEffort                  effort
-	selectedRepo                  repo
-	activeView                      viewOption
-	loading                         bool
-	spinner                         spinner.Model
+	selectedRepo                    repo
+	doctorIssues                    []doctorIssue
+	adoptionCandidates              []adoptionCandidate
+	worktreeChanges                 []worktreeChanges
+	commitStageAll                  bool
+	searchMatches                   []searchMatch
+	diffs                           []repoDiff
+	unfinishedOperations            []*operationJournal
+	historyEntries                  []operationLogEntry
+	historyFilter                   operationLogFilter
+	effortDetails                   []effortRepoDetail
+	// historyShowOutput expands the output of the history entry under the cursor
+	historyShowOutput bool
+	// effortOrder is how the efforts list is sorted, cycled with o
+	effortOrder effortSortOrder
+	// repoSelectionUndo is which repos were selected before the last change in the edit effort view, nil when there
+	// is nothing to undo
+	repoSelectionUndo map[int64]bool
+	// selectingRange is set while J and K extend a selection from rangeAnchor
+	selectingRange bool
+	rangeAnchor    int
+	// pickingOtherEffort shows the efforts whose repos can be added to the selection, otherEffortCursor is the one
+	// under the cursor
+	pickingOtherEffort bool
+	otherEffortCursor  int
+	activeView         viewOption
+	loading            bool
+	spinner            spinner.Model
+	// previousView is where esc returns to for views that can be reached from more than one place
+	previousView viewOption
+	// report is the summary of the last effort wide action
+	report string
+	// windowHeight is used by views that scroll
+	windowHeight int
+	// statusMsg is feedback that isn't an error, e.g., where a file was written
+	statusMsg string
+	// retry replays the key press that started the last operation, nil when there is nothing to retry
+	retry *retryState
+	// forceDelete makes the delete effort view throw away unmerged and uncommitted work
+	forceDelete bool
+	// forcePush makes the delete effort view confirm a push --force-with-lease of every repo instead of a delete
+	forcePush bool
 	// a filter is being created
 	listFilterLive bool
 	// a filter has been applied to the list
@@ -51,6 +98,12 @@ type model struct {
 	validationMsg string
 }
 
+// retryState is the model as it was right before the key press that started an operation
+type retryState struct {
+	model model
+	key   tea.KeyMsg
+}
+
 // modelData can't use the model itself because apparently channels have a size limit of 64kb
 type modelData struct {
 	resetControls bool
@@ -58,6 +111,17 @@ type modelData struct {
 	validationMsg string
 	activeView    viewOption
 	repos         list.Model
+	doctorIssues  []doctorIssue
+	// adoptionCandidates is only set when scanning for branches to adopt
+	adoptionCandidates []adoptionCandidate
+	report             string
+	worktreeChanges    []worktreeChanges
+	searchMatches      []searchMatch
+	diffs              []repoDiff
+	// unfinishedOperations is what is left to recover after resuming or rolling back an operation
+	unfinishedOperations []*operationJournal
+	historyEntries       []operationLogEntry
+	effortDetails        []effortRepoDetail
 }
 
 type viewOption string
@@ -70,6 +134,16 @@ const (
 	activeViewDeleteEffort viewOption = "de"
 	activeViewDeleteRepo   viewOption = "dr"
 	activeViewEditEffort   viewOption = "ee"
+	activeViewDoctor       viewOption = "doc"
+	activeViewAdopt        viewOption = "ad"
+	activeViewReport       viewOption = "rep"
+	activeViewCommit       viewOption = "com"
+	activeViewExec         viewOption = "ex"
+	activeViewSearch       viewOption = "se"
+	activeViewDiff         viewOption = "di"
+	activeViewRecover      viewOption = "rec"
+	activeViewHistory      viewOption = "hi"
+	activeViewEffortDetail viewOption = "det"
 )
 
 var loadingFinished = make(chan modelData, 1)
@@ -79,6 +153,11 @@ var deleteItemKeyBinding = key.NewBinding(
 	key.WithHelp("d", "delete"),
 )
 
+var forceDeleteEffortBinding = key.NewBinding(
+	key.WithKeys("D"),
+	key.WithHelp("D", "force delete"),
+)
+
 var addItemKeyBinding = key.NewBinding(
 	key.WithKeys("a"),
 	key.WithHelp("a", "add"),
@@ -94,9 +173,65 @@ var navigateToReposBinding = key.NewBinding(
 	key.WithHelp("r", "repos"),
 )
 
+var navigateToAdoptBinding = key.NewBinding(
+	key.WithKeys("A"),
+	key.WithHelp("A", "adopt branches"),
+)
+
+var updateFromTrunkBinding = key.NewBinding(
+	key.WithKeys("u"),
+	key.WithHelp("u", "update from trunk"),
+)
+
+var commitEffortBinding = key.NewBinding(
+	key.WithKeys("c"),
+	key.WithHelp("c", "commit"),
+)
+
+var pushEffortBinding = key.NewBinding(
+	key.WithKeys("p"),
+	key.WithHelp("p", "push"),
+)
+
+var forcePushEffortBinding = key.NewBinding(
+	key.WithKeys("P"),
+	key.WithHelp("P", "push --force-with-lease"),
+)
+
+var execInEffortBinding = key.NewBinding(
+	key.WithKeys("x"),
+	key.WithHelp("x", "run command in every repo"),
+)
+
+var searchEffortBinding = key.NewBinding(
+	key.WithKeys("s"),
+	key.WithHelp("s", "search every repo"),
+)
+
+var diffEffortBinding = key.NewBinding(
+	key.WithKeys("v"),
+	key.WithHelp("v", "diff against trunk"),
+)
+
+var navigateToHistoryBinding = key.NewBinding(
+	key.WithKeys("H"),
+	key.WithHelp("H", "history"),
+)
+
+var cycleEffortOrderBinding = key.NewBinding(
+	key.WithKeys("o"),
+	key.WithHelp("o", "sort by recency/name/status"),
+)
+
+var navigateToDoctorBinding = key.NewBinding(
+	key.WithKeys("!"),
+	key.WithHelp("!", "doctor"),
+)
+
 func initModel() (model, error) {
 	var wg sync.WaitGroup
-	errChan := make(chan error, 1)
+	// sized for every goroutine so a second failure can never block
+	errChan := make(chan error, 2)
 
 	var repos []list.Item
 	var efforts []list.Item
@@ -107,7 +242,7 @@ func initModel() (model, error) {
 		var e error
 		repos, e = fetchRepos()
 		if e != nil {
-			errChan <- fmt.Errorf("error, when fetchRepos() for initModel(). Error: %v", e)
+			errChan <- fmt.Errorf("error, when fetchRepos() for initModel(). Error: %w", e)
 			return
 		}
 	}()
@@ -118,7 +253,7 @@ func initModel() (model, error) {
 		var e error
 		efforts, e = fetchEfforts()
 		if e != nil {
-			errChan <- fmt.Errorf("error, when fetchEfforts() for initModel(). Error: %v", e)
+			errChan <- fmt.Errorf("error, when fetchEfforts() for initModel(). Error: %w", e)
 			return
 		}
 	}()
@@ -129,7 +264,13 @@ func initModel() (model, error) {
 	}()
 
 	if errChanError := <-errChan; errChanError != nil {
-		return model{}, fmt.Errorf("error, when attempting to fetch data. Error: %v", errChanError)
+		return model{}, fmt.Errorf("error, when attempting to fetch data. Error: %w", errChanError)
+	}
+
+	// operations a crash interrupted are dealt with before anything else
+	unfinishedOperations, err := fetchUnfinishedOperations()
+	if err != nil {
+		return model{}, fmt.Errorf("error, when fetchUnfinishedOperations() for initModel(). Error: %w", err)
 	}
 
 	repoTextInput := textinput.New()
@@ -159,6 +300,26 @@ func initModel() (model, error) {
 	deleteRepoTextInput.CharLimit = 32
 	deleteRepoTextInput.Width = 32
 
+	commitMessageTextInput := textinput.New()
+	commitMessageTextInput.Placeholder = "Commit message, the ticket id is prefixed automatically"
+	commitMessageTextInput.CharLimit = 200
+	commitMessageTextInput.Width = 72
+
+	execCommandTextInput := textinput.New()
+	execCommandTextInput.Placeholder = "go test ./..."
+	execCommandTextInput.CharLimit = 200
+	execCommandTextInput.Width = 72
+
+	searchTextInput := textinput.New()
+	searchTextInput.Placeholder = "pattern passed to git grep"
+	searchTextInput.CharLimit = 100
+	searchTextInput.Width = 50
+
+	historyFilterTextInput := textinput.New()
+	historyFilterTextInput.Placeholder = "text in the action, repo, command or output"
+	historyFilterTextInput.CharLimit = 100
+	historyFilterTextInput.Width = 50
+
 	listFilter := textinput.New()
 	listFilter.Placeholder = "no active filter"
 	listFilter.CharLimit = 15
@@ -173,9 +334,17 @@ func initModel() (model, error) {
 			navigateToEffortsBinding,
 		}
 	}
+	theRepos.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			navigateToHistoryBinding,
+			navigateToDoctorBinding,
+		}
+	}
 
-	theEfforts := list.New(efforts, list.NewDefaultDelegate(), 0, 0)
-	theEfforts.Title = "Efforts"
+	theEfforts := list.New(sortEfforts(efforts, effortSortRecency, time.Now()), newEffortDelegate(), 0, 0)
+	theEfforts.Title = effortsListTitle(effortSortRecency)
+	theEfforts.Filter = filterEfforts
+	theEfforts.FilterInput.Placeholder = "repo:billing branch:ABC- status:active text"
 	theEfforts.AdditionalShortHelpKeys = func() []key.Binding {
 		return []key.Binding{
 			addItemKeyBinding,
@@ -183,6 +352,22 @@ func initModel() (model, error) {
 			navigateToReposBinding,
 		}
 	}
+	theEfforts.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			forceDeleteEffortBinding,
+			commitEffortBinding,
+			pushEffortBinding,
+			forcePushEffortBinding,
+			updateFromTrunkBinding,
+			execInEffortBinding,
+			searchEffortBinding,
+			diffEffortBinding,
+			cycleEffortOrderBinding,
+			navigateToAdoptBinding,
+			navigateToHistoryBinding,
+			navigateToDoctorBinding,
+		}
+	}
 
 	m := model{
 		addNewRepoTextInput:             repoTextInput,
@@ -191,21 +376,53 @@ func initModel() (model, error) {
 		deleteEffortTextInput:           deleteEffortTextInput,
 		deleteRepoTextInput:             deleteRepoTextInput,
 		listFilterTextInput:             listFilter,
+		commitMessageTextInput:          commitMessageTextInput,
+		execCommandTextInput:            execCommandTextInput,
+		searchTextInput:                 searchTextInput,
+		historyFilterTextInput:          historyFilterTextInput,
 		repos:                           theRepos,
 		activeView:                      activeViewListEfforts,
 		efforts:                         theEfforts,
+		effortOrder:                     effortSortRecency,
+		unfinishedOperations:            unfinishedOperations,
 		err:                             nil,
 	}
+	if len(unfinishedOperations) != 0 {
+		m.activeView = activeViewRecover
+	}
 	m.resetSpinner()
 	return m, nil
 }
 
 func main() {
+	// the db commands run before migrating so status can report pending and modified migrations and restore can recover
+	// a database that no longer migrates
+	if len(os.Args) > 1 && os.Args[1] == "db" {
+		err := runDbCommand(os.Args[2:])
+		if err != nil {
+			log.Fatalf("error, when running command. Error: %v", err)
+		}
+		return
+	}
+
 	err := ProcessSchemaChanges(databaseFiles)
 	if err != nil {
 		log.Fatalf("error, when processing schema changes. Error: %v", err)
 	}
 
+	err = pruneOperationLog()
+	if err != nil {
+		log.Fatalf("error, when pruneOperationLog() for main(). Error: %v", err)
+	}
+
+	if len(os.Args) > 1 {
+		err = runCli(os.Args[1:])
+		if err != nil {
+			log.Fatalf("error, when running command. Error: %v", err)
+		}
+		return
+	}
+
 	m, err := initModel()
 	if err != nil {
 		log.Fatalf("error, when initModel() for main(). Error: %v", err)
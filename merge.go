@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// mergeMethod is how the effort branch was found to be in trunk, the empty string means it wasn't
+type mergeMethod string
+
+const (
+	mergeMethodAncestor    mergeMethod = "ancestry (merge or fast-forward)"
+	mergeMethodPatchId     mergeMethod = "patch ids of every commit (rebase merge)"
+	mergeMethodTree        mergeMethod = "identical files"
+	mergeMethodSquash      mergeMethod = "patch id of the combined change (squash merge)"
+	mergeMethodPullRequest mergeMethod = "pull request state from prStateCommand"
+)
+
+// detectMerge checks whether every change on ref made it into trunkRef, trying the cheap exact checks before the ones
+// that catch rebase and squash merges. ref is the local or remote-tracking ref of the effort branch while branchName is
+// the plain branch name the forge knows the pull request by. dir can be the bare repo or any of its worktrees.
+func detectMerge(dir string, ref string, branchName string, trunkRef string) (mergeMethod, error) {
+	_, err := runGitCommand(dir, "merge-base", "--is-ancestor", ref, trunkRef)
+	if err == nil {
+		return mergeMethodAncestor, nil
+	}
+
+	// git cherry marks the commits that have no patch equivalent upstream with +
+	cherry, err := runGitCommand(dir, "cherry", trunkRef, ref)
+	if err != nil {
+		return "", fmt.Errorf("error, when comparing patch ids for detectMerge(). Error: %w", err)
+	}
+	if !strings.Contains("\n"+cherry, "\n+") {
+		return mergeMethodPatchId, nil
+	}
+
+	_, err = runGitCommand(dir, "diff", "--quiet", trunkRef, ref)
+	if err == nil {
+		return mergeMethodTree, nil
+	}
+
+	// a squash merge is one commit holding every change of the branch, so the branch is squashed into a throwaway
+	// commit on top of the merge base and that commit's patch id is looked for in trunk
+	mergeBase, err := runGitCommand(dir, "merge-base", trunkRef, ref)
+	if err != nil {
+		return "", fmt.Errorf("error, when finding merge base for detectMerge(). Error: %w", err)
+	}
+	// the identity is fixed since it doesn't affect the patch id and the user may not have one configured
+	squashed, err := runGitCommand(
+		dir,
+		"-c", "user.name=git-tool",
+		"-c", "user.email=git-tool@localhost",
+		"commit-tree", ref+"^{tree}", "-p", mergeBase, "-m", "squashed "+ref,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error, when squashing branch for detectMerge(). Error: %w", err)
+	}
+	cherry, err = runGitCommand(dir, "cherry", trunkRef, squashed)
+	if err != nil {
+		return "", fmt.Errorf("error, when comparing squashed patch id for detectMerge(). Error: %w", err)
+	}
+	if strings.HasPrefix(cherry, "-") {
+		return mergeMethodSquash, nil
+	}
+
+	if config.PrStateCommand != "" {
+		merged, err := isPullRequestMerged(dir, branchName)
+		if err != nil {
+			// commands like gh pr view exit non-zero when the branch has no pull request, which is the usual case for a
+			// branch that isn't merged, so the branch is treated as not merged rather than failing the check
+			log.Printf("warning, treating %s as not merged since prStateCommand failed: %v", branchName, err)
+		}
+		if merged {
+			return mergeMethodPullRequest, nil
+		}
+	}
+	return "", nil
+}
+
+// isPullRequestMerged asks the forge through prStateCommand, which gets the branch in $BRANCH and must print MERGED
+// when the pull request of the branch has been merged, e.g., gh pr view "$BRANCH" --json state -q .state
+func isPullRequestMerged(dir string, branch string) (bool, error) {
+	ctx, cancel := newGitContext([]string{"ls-remote"})
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", config.PrStateCommand)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "BRANCH="+branch)
+	cmd.WaitDelay = 5 * time.Second
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("error, when running prStateCommand %q. Output: %s. Error: %w", config.PrStateCommand, output, err)
+	}
+	return strings.EqualFold(strings.TrimSpace(string(output)), "merged"), nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func Test_detectMerge(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		_, err := runGitCommand(dir, append([]string{"-c", "user.name=test", "-c", "user.email=test@test"}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(file string, content string) {
+		t.Helper()
+		err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		git("add", file)
+		git("commit", "-m", file+" "+content)
+	}
+
+	git("init", "-b", "main")
+	commit("base.txt", "base")
+
+	git("switch", "-c", "merged")
+	commit("merged.txt", "merged")
+	git("switch", "main")
+	git("merge", "--no-ff", "merged")
+
+	git("switch", "-c", "rebased", "main~1")
+	commit("rebased.txt", "rebased")
+	git("switch", "main")
+	git("cherry-pick", "rebased")
+
+	git("switch", "-c", "squashed", "main~1")
+	commit("squashed.txt", "one")
+	commit("squashed.txt", "two")
+	git("switch", "main")
+	git("merge", "--squash", "squashed")
+	git("commit", "-m", "squashed")
+	commit("after.txt", "after")
+
+	git("switch", "-c", "unmerged", "main~1")
+	commit("unmerged.txt", "unmerged")
+	git("switch", "main")
+
+	tests := []struct {
+		branch   string
+		expected mergeMethod
+	}{
+		{"merged", mergeMethodAncestor},
+		{"rebased", mergeMethodPatchId},
+		{"squashed", mergeMethodSquash},
+		{"unmerged", ""},
+	}
+	for _, test := range tests {
+		got, err := detectMerge(dir, test.branch, test.branch, "main")
+		if err != nil {
+			t.Fatalf("detectMerge() of %s failed. Error: %v", test.branch, err)
+		}
+		if got != test.expected {
+			t.Errorf("got %q for %s, but wanted %q", got, test.branch, test.expected)
+		}
+	}
+
+	// the pull request is looked up by the plain branch name even when the ref checked is the remote-tracking one
+	git("update-ref", "refs/remotes/origin/ABC-1", "unmerged")
+	previousPrStateCommand := config.PrStateCommand
+	config.PrStateCommand = `if [ "$BRANCH" = ABC-1 ]; then echo MERGED; else echo "no pull request for $BRANCH" >&2; exit 1; fi`
+	t.Cleanup(func() { config.PrStateCommand = previousPrStateCommand })
+	got, err := detectMerge(dir, "origin/ABC-1", "ABC-1", "main")
+	if err != nil {
+		t.Fatalf("detectMerge() of origin/ABC-1 failed. Error: %v", err)
+	}
+	if got != mergeMethodPullRequest {
+		t.Errorf("got %q for origin/ABC-1, but wanted %q", got, mergeMethodPullRequest)
+	}
+
+	// a branch without a pull request makes the command exit 1, that is an unmerged branch rather than a failed check
+	git("update-ref", "refs/remotes/origin/ABC-2", "unmerged")
+	got, err = detectMerge(dir, "origin/ABC-2", "ABC-2", "main")
+	if err != nil {
+		t.Fatalf("detectMerge() of origin/ABC-2 failed. Error: %v", err)
+	}
+	if got != "" {
+		t.Errorf("got %q for origin/ABC-2, but wanted it not merged", got)
+	}
+}
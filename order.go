@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// effortStatus is derived from the repos and timestamps of an effort rather than stored
+type effortStatus string
+
+const (
+	effortStatusActive effortStatus = "active"
+	// effortStatusIdle is an effort with repos that hasn't been opened or updated in idleEffortDays
+	effortStatusIdle effortStatus = "idle"
+	// effortStatusEmpty is an effort without any repos yet
+	effortStatusEmpty effortStatus = "empty"
+)
+
+// effortStatusRanks puts what is being worked on first when sorting by status
+var effortStatusRanks = map[effortStatus]int{
+	effortStatusActive: 0,
+	effortStatusIdle:   1,
+	effortStatusEmpty:  2,
+}
+
+type effortSortOrder string
+
+const (
+	effortSortRecency effortSortOrder = "recency"
+	effortSortName    effortSortOrder = "name"
+	effortSortStatus  effortSortOrder = "status"
+)
+
+// effortSortOrders is the order the sort key cycles through
+var effortSortOrders = []effortSortOrder{effortSortRecency, effortSortName, effortSortStatus}
+
+func (o effortSortOrder) next() effortSortOrder {
+	for i, order := range effortSortOrders {
+		if order == o {
+			return effortSortOrders[(i+1)%len(effortSortOrders)]
+		}
+	}
+	return effortSortOrders[0]
+}
+
+// lastActivity is when the effort was last opened, updated or created, whichever is latest
+func (e effort) lastActivity() time.Time {
+	latest := e.CreatedAt
+	for _, t := range []time.Time{e.UpdatedAt, e.LastOpenedAt} {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+func (e effort) status(now time.Time) effortStatus {
+	if len(e.Repos) == 0 {
+		return effortStatusEmpty
+	}
+	if now.Sub(e.lastActivity()) > time.Duration(config.IdleEffortDays)*24*time.Hour {
+		return effortStatusIdle
+	}
+	return effortStatusActive
+}
+
+// sortEfforts orders the efforts in place, ties are broken by name so the order is stable between refreshes
+func sortEfforts(efforts []list.Item, order effortSortOrder, now time.Time) []list.Item {
+	sort.SliceStable(efforts, func(i, j int) bool {
+		a := efforts[i].(effort)
+		b := efforts[j].(effort)
+		switch order {
+		case effortSortRecency:
+			if !a.lastActivity().Equal(b.lastActivity()) {
+				return a.lastActivity().After(b.lastActivity())
+			}
+		case effortSortStatus:
+			aRank := effortStatusRanks[a.status(now)]
+			bRank := effortStatusRanks[b.status(now)]
+			if aRank != bRank {
+				return aRank < bRank
+			}
+			if !a.lastActivity().Equal(b.lastActivity()) {
+				return a.lastActivity().After(b.lastActivity())
+			}
+		}
+		return a.Name < b.Name
+	})
+	return efforts
+}
+
+// formatAge is a rough how long ago for the efforts list, e.g., 5m, 3h or 12d
+func formatAge(t time.Time, now time.Time) string {
+	age := now.Sub(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
+
+// parseEffortTimestamp is lenient since efforts from before timestamps were tracked have none
+func parseEffortTimestamp(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func markEffortUpdated(effortId int64) error {
+	_, err := database.Exec(`UPDATE effort SET updated_at = ? WHERE id = ?`, time.Now().UTC().Format(time.RFC3339), effortId)
+	if err != nil {
+		return fmt.Errorf("error, when setting updated_at for markEffortUpdated(). Error: %w", err)
+	}
+	return nil
+}
+
+func markEffortOpened(effortId int64) error {
+	_, err := database.Exec(`UPDATE effort SET last_opened_at = ? WHERE id = ?`, time.Now().UTC().Format(time.RFC3339), effortId)
+	if err != nil {
+		return fmt.Errorf("error, when setting last_opened_at for markEffortOpened(). Error: %w", err)
+	}
+	return nil
+}
+
+// markEffortUpdatedAfter records that an effort wide action changed something in at least one repo. The action has
+// already happened by now so failing to record it is only logged.
+func markEffortUpdatedAfter(theEffort effort, results []repoResult) {
+	for _, result := range results {
+		if result.Outcome == repoOutcomeSucceeded {
+			err := markEffortUpdated(theEffort.Id)
+			if err != nil {
+				log.Printf("error, when markEffortUpdated() for %s. Error: %v", theEffort.Name, err)
+			}
+			return
+		}
+	}
+}
+
+func effortsListTitle(order effortSortOrder) string {
+	return "Efforts by " + string(order)
+}
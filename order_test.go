@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func Test_sortEfforts(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	efforts := []list.Item{
+		effort{Name: "billing", Repos: []repo{{Url: "a"}, {Url: "b"}}, CreatedAt: now.AddDate(0, 0, -30), LastOpenedAt: now.AddDate(0, 0, -20)},
+		effort{Name: "auth", CreatedAt: now.Add(-time.Hour)},
+		effort{Name: "search", Repos: []repo{{Url: "a"}}, CreatedAt: now.AddDate(0, 0, -3), UpdatedAt: now.AddDate(0, 0, -2)},
+		effort{Name: "legacy", Repos: []repo{{Url: "a"}}},
+	}
+	tests := []struct {
+		order effortSortOrder
+		want  string
+	}{
+		{order: effortSortRecency, want: "auth,search,billing,legacy"},
+		{order: effortSortName, want: "auth,billing,legacy,search"},
+		{order: effortSortStatus, want: "search,billing,legacy,auth"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			var names []string
+			for _, item := range sortEfforts(efforts, tt.order, now) {
+				names = append(names, item.(effort).Name)
+			}
+			got := strings.Join(names, ",")
+			if got != tt.want {
+				t.Errorf("got %s, but wanted %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_effortStatus(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		effort effort
+		want   effortStatus
+	}{
+		{name: "no repos", effort: effort{CreatedAt: now}, want: effortStatusEmpty},
+		{name: "opened recently", effort: effort{Repos: []repo{{Url: "a"}}, CreatedAt: now.AddDate(0, -2, 0), LastOpenedAt: now.AddDate(0, 0, -1)}, want: effortStatusActive},
+		{name: "untouched for weeks", effort: effort{Repos: []repo{{Url: "a"}}, CreatedAt: now.AddDate(0, -2, 0), UpdatedAt: now.AddDate(0, 0, -15)}, want: effortStatusIdle},
+		{name: "from before timestamps", effort: effort{Repos: []repo{{Url: "a"}}}, want: effortStatusIdle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.effort.status(now)
+			if got != tt.want {
+				t.Errorf("got %s, but wanted %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_effortSortOrder_next(t *testing.T) {
+	order := effortSortRecency
+	var seen []string
+	for range effortSortOrders {
+		order = order.next()
+		seen = append(seen, string(order))
+	}
+	if strings.Join(seen, ",") != "name,status,recency" {
+		t.Errorf("got %v, but wanted every order once before coming back to recency", seen)
+	}
+}
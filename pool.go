@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// runWorkerPool calls work for every index below count with at most concurrency calls running at once. Every worker
+// has exited by the time it returns so nothing is left running in the background.
+func runWorkerPool(count int, concurrency int, work func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(concurrency, 1), count) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				work(i)
+			}
+		}()
+	}
+	for i := range count {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// runRepoTasks runs the git task against every repo, gitConcurrency at a time. The results are in the same order as
+// repos, every failure is kept, not just the first, and the detail a task returns is kept when it succeeds.
+func runRepoTasks(repos []repo, task func(r repo) (string, error)) []repoResult {
+	results := make([]repoResult, len(repos))
+	runWorkerPool(len(repos), config.GitConcurrency, func(i int) {
+		results[i] = repoResult{theRepo: repos[i], Outcome: repoOutcomeSucceeded}
+		if operationCancelled() {
+			results[i].Outcome = repoOutcomeCancelled
+			results[i].Detail = "not started"
+			results[i].Err = fmt.Errorf("cancelled before it started")
+			return
+		}
+		detail, err := task(repos[i])
+		results[i].Detail = detail
+		if err != nil {
+			results[i].Outcome = repoOutcomeFailed
+			results[i].Detail = err.Error()
+			results[i].Err = err
+			results[i] = markIfCancelled(results[i])
+		}
+	})
+	return results
+}
+
+// markIfCancelled reports a repo that failed because the operation was cancelled as cancelled rather than failed
+func markIfCancelled(result repoResult) repoResult {
+	if result.Outcome == repoOutcomeFailed && operationCancelled() {
+		result.Outcome = repoOutcomeCancelled
+	}
+	return result
+}
+
+// repoResultsError joins the error of every failed repo, it is nil when none failed
+func repoResultsError(results []repoResult) error {
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", r.theRepo.Title(), r.Outcome, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_runWorkerPool(t *testing.T) {
+	var running, peak, calls atomic.Int32
+	runWorkerPool(20, 3, func(i int) {
+		current := running.Add(1)
+		for {
+			previous := peak.Load()
+			if current <= previous || peak.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		calls.Add(1)
+	})
+	if calls.Load() != 20 {
+		t.Errorf("got %d calls, but wanted 20", calls.Load())
+	}
+	if peak.Load() > 3 {
+		t.Errorf("got %d running at once, but wanted at most 3", peak.Load())
+	}
+}
+
+func Test_runRepoTasks(t *testing.T) {
+	repos := []repo{
+		{Url: "git@github.com:test/alpha.git"},
+		{Url: "git@github.com:test/beta.git"},
+		{Url: "git@github.com:test/gamma.git"},
+	}
+	results := runRepoTasks(repos, func(r repo) (string, error) {
+		if r.Title() == "alpha" {
+			return "", nil
+		}
+		return "", errors.New("boom")
+	})
+	if results[0].Outcome != repoOutcomeSucceeded || results[1].Outcome != repoOutcomeFailed || results[2].Outcome != repoOutcomeFailed {
+		t.Errorf("got outcomes %s %s %s, but wanted succeeded failed failed", results[0].Outcome, results[1].Outcome, results[2].Outcome)
+	}
+	err := repoResultsError(results)
+	if err == nil || !strings.Contains(err.Error(), "beta failed: boom") || !strings.Contains(err.Error(), "gamma failed: boom") {
+		t.Errorf("got %v, but wanted both beta and gamma failures", err)
+	}
+	if repoResultsError(results[:1]) != nil {
+		t.Errorf("got an error when nothing failed")
+	}
+}
+
+func Test_runRepoTasks_cancelled(t *testing.T) {
+	cancelGitOperation()
+	defer beginOperation(context.Background())
+	called := false
+	results := runRepoTasks([]repo{{Url: "git@github.com:test/alpha.git"}}, func(r repo) (string, error) {
+		called = true
+		return "", nil
+	})
+	if called {
+		t.Errorf("task ran after the operation was cancelled")
+	}
+	if results[0].Outcome != repoOutcomeCancelled {
+		t.Errorf("got %s, but wanted %s", results[0].Outcome, repoOutcomeCancelled)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// pushEffort pushes the effort branch of every worktree, setting the upstream. Trunk is never pushed.
+func pushEffort(theEffort effort, forceWithLease bool, runPrePush bool) ([]repoResult, error) {
+	effortRepos, err := fetchReposForEffort(theEffort.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposForEffort() for pushEffort(). Error: %w", err)
+	}
+	results := make([]repoResult, len(effortRepos))
+	runWorkerPool(len(effortRepos), config.GitConcurrency, func(i int) {
+		results[i] = markIfCancelled(pushRepo(theEffort, effortRepos[i], forceWithLease, runPrePush))
+	})
+	markEffortUpdatedAfter(theEffort, results)
+	return results, nil
+}
+
+func pushRepo(theEffort effort, r repo, forceWithLease bool, runPrePush bool) repoResult {
+	result := repoResult{theRepo: r}
+	worktreeDir := getWorktreeDir(theEffort, r)
+	exists, err := checkDirectoryExists(worktreeDir)
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+	if !exists {
+		result.Outcome = repoOutcomeSkipped
+		result.Detail = "worktree is missing"
+		return result
+	}
+
+	trunk, err := getTrunkBranch(r)
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+	currentBranch, err := runGitCommand(worktreeDir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+	if theEffort.BranchName == trunk || currentBranch == trunk {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = fmt.Sprintf("refusing to push to trunk %s", trunk)
+		return result
+	}
+	if currentBranch != theEffort.BranchName {
+		result.Outcome = repoOutcomeSkipped
+		result.Detail = fmt.Sprintf("worktree is on %s instead of %s", currentBranch, theEffort.BranchName)
+		return result
+	}
+
+	if runPrePush {
+		prePushCommand := getPrePushCommand(r)
+		if prePushCommand != "" {
+			// no timeout since test suites can legitimately take a long time, it still stops when the push is cancelled
+			cmd := exec.CommandContext(operationContext(), "sh", "-c", prePushCommand)
+			cmd.Dir = worktreeDir
+			cmd.WaitDelay = 5 * time.Second
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				result.Outcome = repoOutcomeFailed
+				result.Detail = fmt.Sprintf("pre-push command %q failed, not pushed: %s", prePushCommand, lastLines(string(output), 5))
+				return result
+			}
+		}
+	}
+
+	// an explicit refspec guarantees the push can only ever update the effort branch
+	args := []string{"push", "--set-upstream"}
+	if forceWithLease {
+		args = append(args, "--force-with-lease")
+	}
+	args = append(args, "origin", fmt.Sprintf("refs/heads/%s:refs/heads/%s", theEffort.BranchName, theEffort.BranchName))
+	output, err := runGitCommand(worktreeDir, args...)
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+	result.Outcome = repoOutcomeSucceeded
+	if strings.Contains(output, "Everything up-to-date") {
+		result.Detail = "already up to date"
+	} else {
+		result.Detail = "pushed " + theEffort.BranchName
+	}
+	return result
+}
+
+func getPrePushCommand(r repo) string {
+	command, ok := config.RepoPrePushCommands[r.Title()]
+	if ok {
+		return command
+	}
+	return config.PrePushCommand
+}
+
+func lastLines(output string, count int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > count {
+		lines = lines[len(lines)-count:]
+	}
+	return strings.Join(lines, "\n")
+}
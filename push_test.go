@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_pushRepo(t *testing.T) {
+	r, git := setUpClonedRepo(t)
+	bareDir := getBareRepoDir(r)
+	origin := filepath.Join(os.Getenv("HOME"), "origin")
+
+	theEffort := effort{Name: "feature", BranchName: "ABC-1"}
+	worktreeDir := getWorktreeDir(theEffort, r)
+	git(bareDir, "worktree", "add", "-b", theEffort.BranchName, worktreeDir, "main")
+	git(worktreeDir, "commit", "--allow-empty", "-m", "work")
+
+	result := pushRepo(theEffort, r, false, false)
+	if result.Outcome != repoOutcomeSucceeded {
+		t.Fatalf("got %s: %s, but wanted the effort branch pushed", result.Outcome, result.Detail)
+	}
+	if pushed := git(origin, "log", "-1", "--format=%s", theEffort.BranchName); pushed != "work" {
+		t.Errorf("got %q on the remote, but wanted the pushed commit", pushed)
+	}
+
+	// a worktree switched to trunk is never pushed
+	git(worktreeDir, "switch", "--detach")
+	git(bareDir, "branch", "-f", "main", theEffort.BranchName)
+	git(worktreeDir, "switch", "main")
+	result = pushRepo(theEffort, r, false, false)
+	if result.Outcome != repoOutcomeFailed || !strings.Contains(result.Detail, "refusing to push to trunk main") {
+		t.Errorf("got %s: %s, but wanted the push to trunk refused", result.Outcome, result.Detail)
+	}
+
+	// nor is an effort whose branch is trunk
+	trunkEffort := effort{Name: "trunk", BranchName: "main"}
+	trunkWorktreeDir := getWorktreeDir(trunkEffort, r)
+	err := os.MkdirAll(filepath.Dir(trunkWorktreeDir), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(bareDir, "worktree", "move", worktreeDir, trunkWorktreeDir)
+	result = pushRepo(trunkEffort, r, false, false)
+	if result.Outcome != repoOutcomeFailed || !strings.Contains(result.Detail, "refusing to push to trunk main") {
+		t.Errorf("got %s: %s, but wanted the push to trunk refused", result.Outcome, result.Detail)
+	}
+	if remote := git(origin, "log", "-1", "--format=%s", "main"); remote != "base" {
+		t.Errorf("got %q on the remote trunk, but wanted it untouched", remote)
+	}
+}
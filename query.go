@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// effortQuery is the efforts list filter, e.g., repo:billing branch:ABC- status:active free text. Every term has to
+// match, case insensitively, somewhere in its field.
+type effortQuery struct {
+	Repos    []string
+	Branches []string
+	Statuses []string
+	// Text is matched against the name, description and branch
+	Text []string
+}
+
+// effortQueryFields are the field: prefixes a query term can have, ticket is another name for branch
+var effortQueryFields = map[string]bool{"repo": true, "branch": true, "ticket": true, "status": true}
+
+func parseEffortQuery(query string) effortQuery {
+	var q effortQuery
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		field, value, found := strings.Cut(term, ":")
+		if !found || !effortQueryFields[field] {
+			q.Text = append(q.Text, term)
+			continue
+		}
+		// the value is still being typed
+		if value == "" {
+			continue
+		}
+		switch field {
+		case "repo":
+			q.Repos = append(q.Repos, value)
+		case "branch", "ticket":
+			q.Branches = append(q.Branches, value)
+		case "status":
+			q.Statuses = append(q.Statuses, value)
+		}
+	}
+	return q
+}
+
+func (q effortQuery) matches(f effortSearchFields) bool {
+	for _, term := range q.Repos {
+		if len(f.matchingRepos([]string{term})) == 0 {
+			return false
+		}
+	}
+	for _, term := range q.Branches {
+		if !containsFold(f.Branch, term) {
+			return false
+		}
+	}
+	for _, term := range q.Statuses {
+		if !containsFold(f.Status, term) {
+			return false
+		}
+	}
+	for _, term := range q.Text {
+		if !containsFold(f.Name, term) && !containsFold(f.Desc, term) && !containsFold(f.Branch, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// effortSearchFields is what an effort is searched and displayed by in the efforts list
+type effortSearchFields struct {
+	Name   string
+	Desc   string
+	Branch string
+	Status string
+	// Age is how long ago the effort was last used, empty when that isn't known
+	Age   string
+	Repos []string
+}
+
+// effortSearchFieldSeparator and effortSearchRepoSeparator can't be typed into any of the fields
+const (
+	effortSearchFieldSeparator = "\x1f"
+	effortSearchRepoSeparator  = "\x1e"
+)
+
+func (e effort) searchFields(now time.Time) effortSearchFields {
+	f := effortSearchFields{
+		Name:   e.Name,
+		Desc:   e.Desc,
+		Branch: e.BranchName,
+		Status: string(e.status(now)),
+	}
+	if !e.lastActivity().IsZero() {
+		f.Age = formatAge(e.lastActivity(), now)
+	}
+	for _, r := range e.Repos {
+		f.Repos = append(f.Repos, r.Title())
+	}
+	return f
+}
+
+// encode packs the fields into the one string a list filter gets per item
+func (f effortSearchFields) encode() string {
+	return strings.Join([]string{f.Name, f.Desc, f.Branch, f.Status, f.Age, strings.Join(f.Repos, effortSearchRepoSeparator)}, effortSearchFieldSeparator)
+}
+
+func decodeEffortSearchFields(value string) (effortSearchFields, error) {
+	parts := strings.Split(value, effortSearchFieldSeparator)
+	if len(parts) != 6 {
+		return effortSearchFields{}, fmt.Errorf("expected 6 fields but got %d in %q", len(parts), value)
+	}
+	f := effortSearchFields{Name: parts[0], Desc: parts[1], Branch: parts[2], Status: parts[3], Age: parts[4]}
+	if parts[5] != "" {
+		f.Repos = strings.Split(parts[5], effortSearchRepoSeparator)
+	}
+	return f, nil
+}
+
+func (f effortSearchFields) matchingRepos(terms []string) []string {
+	var result []string
+	for _, r := range f.Repos {
+		for _, term := range terms {
+			if containsFold(r, term) {
+				result = append(result, r)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// descriptionLine is the line under the effort name, query is nil when the list isn't filtered
+func (f effortSearchFields) descriptionLine(query *effortQuery) *highlightedLine {
+	var q effortQuery
+	if query != nil {
+		q = *query
+	}
+	line := &highlightedLine{}
+	line.add(f.Desc, q.Text)
+	line.add(" • ", nil)
+	line.add(f.Branch, append(append([]string{}, q.Branches...), q.Text...))
+	line.add(" • ", nil)
+	line.add(f.Status, q.Statuses)
+	if f.Age != "" {
+		line.add(" • ", nil)
+		line.add(f.Age, nil)
+	}
+	repos := f.Repos
+	// only the repos that matched are shown so they aren't cut off by the width of the list
+	if len(q.Repos) != 0 {
+		repos = f.matchingRepos(q.Repos)
+	}
+	if len(repos) != 0 {
+		line.add(" • ", nil)
+		for i, r := range repos {
+			if i != 0 {
+				line.add(", ", nil)
+			}
+			line.add(r, q.Repos)
+		}
+	}
+	return line
+}
+
+// filterEfforts is the list.FilterFunc of the efforts list, the targets are encoded effortSearchFields. The efforts
+// stay in the order the list is sorted by rather than being ranked by how well they match.
+func filterEfforts(term string, targets []string) []list.Rank {
+	query := parseEffortQuery(term)
+	var result []list.Rank
+	for i, target := range targets {
+		f, err := decodeEffortSearchFields(target)
+		if err != nil {
+			continue
+		}
+		if query.matches(f) {
+			result = append(result, list.Rank{Index: i})
+		}
+	}
+	return result
+}
+
+// highlightedLine is plain text along with the runes in it that a query matched
+type highlightedLine struct {
+	text    strings.Builder
+	runes   int
+	matched []int
+}
+
+func (l *highlightedLine) add(text string, terms []string) {
+	for _, i := range matchedRuneIndexes(text, terms) {
+		l.matched = append(l.matched, l.runes+i)
+	}
+	l.text.WriteString(text)
+	l.runes += len([]rune(text))
+}
+
+func (l *highlightedLine) String() string {
+	return l.text.String()
+}
+
+// render truncates the line to width and styles the matched runes
+func (l *highlightedLine) render(width int, matched lipgloss.Style, unmatched lipgloss.Style) string {
+	text := ansi.Truncate(l.text.String(), width, "…")
+	visible := len([]rune(text))
+	if visible < l.runes {
+		// the ellipsis isn't part of any match
+		visible--
+	}
+	var indexes []int
+	for _, i := range l.matched {
+		if i < visible {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return text
+	}
+	return lipgloss.StyleRunes(text, indexes, matched, unmatched)
+}
+
+// matchedRuneIndexes finds every case insensitive occurrence of the terms in text
+func matchedRuneIndexes(text string, terms []string) []int {
+	runes := []rune(strings.ToLower(text))
+	// lowering the case can change the number of runes of a few scripts, those are left without highlighting
+	if len(runes) != len([]rune(text)) {
+		return nil
+	}
+	matched := make(map[int]bool)
+	for _, term := range terms {
+		termRunes := []rune(strings.ToLower(term))
+		if len(termRunes) == 0 {
+			continue
+		}
+		for start := 0; start+len(termRunes) <= len(runes); start++ {
+			if string(runes[start:start+len(termRunes)]) == string(termRunes) {
+				for i := range termRunes {
+					matched[start+i] = true
+				}
+			}
+		}
+	}
+	var result []int
+	for i := range runes {
+		if matched[i] {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// effortDelegate renders efforts like list.DefaultDelegate does but highlights every field the query matched
+type effortDelegate struct {
+	list.DefaultDelegate
+}
+
+func newEffortDelegate() effortDelegate {
+	return effortDelegate{DefaultDelegate: list.NewDefaultDelegate()}
+}
+
+func (d effortDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	e, ok := item.(effort)
+	if !ok || m.Width() <= 0 {
+		return
+	}
+	s := &d.Styles
+	fields := e.searchFields(time.Now())
+
+	var query *effortQuery
+	if m.FilterState() != list.Unfiltered {
+		q := parseEffortQuery(m.FilterValue())
+		query = &q
+	}
+	title := &highlightedLine{}
+	if query != nil {
+		title.add(fields.Name, query.Text)
+	} else {
+		title.add(fields.Name, nil)
+	}
+	desc := fields.descriptionLine(query)
+
+	titleStyle, descStyle := s.NormalTitle, s.NormalDesc
+	if m.FilterState() == list.Filtering && m.FilterValue() == "" {
+		titleStyle, descStyle = s.DimmedTitle, s.DimmedDesc
+	} else if index == m.Index() && m.FilterState() != list.Filtering {
+		titleStyle, descStyle = s.SelectedTitle, s.SelectedDesc
+	}
+	width := m.Width() - s.NormalTitle.GetPaddingLeft() - s.NormalTitle.GetPaddingRight()
+	renderedTitle := title.render(width, titleStyle.Inline(true).Inherit(s.FilterMatch), titleStyle.Inline(true))
+	renderedDesc := desc.render(width, descStyle.Inline(true).Inherit(s.FilterMatch), descStyle.Inline(true))
+	fmt.Fprintf(w, "%s\n%s", titleStyle.Render(renderedTitle), descStyle.Render(renderedDesc)) //nolint: errcheck
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parseEffortQuery(t *testing.T) {
+	got := parseEffortQuery("repo:Billing branch:ABC- status:active ticket:9 Free text repo: http://x")
+	want := effortQuery{
+		Repos:    []string{"billing"},
+		Branches: []string{"abc-", "9"},
+		Statuses: []string{"active"},
+		Text:     []string{"free", "text", "http://x"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, but wanted %+v", got, want)
+	}
+}
+
+func Test_filterEfforts(t *testing.T) {
+	now := time.Now()
+	efforts := []effort{
+		{Name: "invoices", Desc: "Invoice emails", BranchName: "ABC-12", CreatedAt: now, Repos: []repo{{Url: "git@example.com:team/billing-api.git"}, {Url: "git@example.com:team/web.git"}}},
+		{Name: "login", Desc: "Login page", BranchName: "XYZ-3", CreatedAt: now, Repos: []repo{{Url: "git@example.com:team/web.git"}}},
+		{Name: "cleanup", Desc: "Old billing code", BranchName: "ABC-40", CreatedAt: now},
+	}
+	targets := make([]string, len(efforts))
+	for i, e := range efforts {
+		targets[i] = e.FilterValue()
+	}
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{query: "", want: []int{0, 1, 2}},
+		{query: "repo:billing", want: []int{0}},
+		{query: "repo:web branch:abc-", want: []int{0}},
+		{query: "status:empty", want: []int{2}},
+		{query: "billing", want: []int{2}},
+		{query: "branch:ABC- page", want: nil},
+		{query: "repo:", want: []int{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []int
+			for _, rank := range filterEfforts(tt.query, targets) {
+				got = append(got, rank.Index)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, but wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_effortSearchFields_descriptionLine(t *testing.T) {
+	f := effortSearchFields{Desc: "Invoices", Branch: "ABC-12", Status: "active", Repos: []string{"billing-api", "web"}}
+	query := parseEffortQuery("repo:bill abc")
+	line := f.descriptionLine(&query)
+	if line.String() != "Invoices • ABC-12 • active • billing-api" {
+		t.Errorf("got %q, but wanted only the repo that matched", line.String())
+	}
+	// ABC of the branch and bill of billing-api
+	want := []int{11, 12, 13, 29, 30, 31, 32}
+	if !reflect.DeepEqual(line.matched, want) {
+		t.Errorf("got matched runes %v, but wanted %v", line.matched, want)
+	}
+}
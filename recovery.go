@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// saveUnmergedWork bundles every commit of the effort branches that isn't in trunk into a new directory under the
+// recovery directory, nothing should be force deleted unless every repo was saved
+func saveUnmergedWork(theEffort effort, effortRepos []repo) (string, []repoResult, error) {
+	recoveryDir := fmt.Sprintf("%s%s_%s/", recoveryDirectory, theEffort.Name, time.Now().Format("20060102_150405"))
+	err := os.MkdirAll(recoveryDir, 0755)
+	if err != nil {
+		return "", nil, fmt.Errorf("error, when creating recovery directory for saveUnmergedWork(). Error: %w", err)
+	}
+	results := runRepoTasks(effortRepos, func(r repo) (string, error) {
+		return saveRepoWork(theEffort, r, recoveryDir)
+	})
+	err = repoResultsError(results)
+	if err != nil {
+		return recoveryDir, results, fmt.Errorf("error, when saveRepoWork() for saveUnmergedWork(). Error: %w", err)
+	}
+	err = os.WriteFile(recoveryDir+"RESTORE.txt", []byte(formatRestoreInstructions(theEffort)), 0644)
+	if err != nil {
+		return recoveryDir, results, fmt.Errorf("error, when writing restore instructions for saveUnmergedWork(). Error: %w", err)
+	}
+	return recoveryDir, results, nil
+}
+
+// saveRepoWork commits anything uncommitted as a WIP commit since the worktree is about to be thrown away, then
+// bundles the local and remote effort branch and any stashed uncommitted work minus what trunk already has
+func saveRepoWork(theEffort effort, r repo, recoveryDir string) (string, error) {
+	worktreeDir := getWorktreeDir(theEffort, r)
+	commandDir := getBareRepoDir(r)
+	exists, err := checkDirectoryExists(worktreeDir)
+	if err != nil {
+		return "", fmt.Errorf("error, when checkDirectoryExists() for saveRepoWork(). Error: %w", err)
+	}
+	if exists {
+		dirty, err := isWorktreeDirty(worktreeDir)
+		if err != nil {
+			return "", fmt.Errorf("error, when isWorktreeDirty() for saveRepoWork(). Error: %w", err)
+		}
+		if dirty {
+			_, err = runGitCommand(worktreeDir, "add", "--all")
+			if err != nil {
+				return "", fmt.Errorf("error, when staging uncommitted work for saveRepoWork(). Error: %w", err)
+			}
+			_, err = runGitCommand(worktreeDir, "commit", "--no-verify", "-m", "WIP saved by git-tool before force deleting "+theEffort.Name)
+			if err != nil {
+				return "", fmt.Errorf("error, when committing uncommitted work for saveRepoWork(). Error: %w", err)
+			}
+		}
+	}
+
+	var refs []string
+	branchExists, err := doesBranchExist(theEffort.BranchName, commandDir)
+	if err != nil {
+		return "", fmt.Errorf("error, when doesBranchExist() for saveRepoWork(). Error: %w", err)
+	}
+	if branchExists {
+		refs = append(refs, "refs/heads/"+theEffort.BranchName)
+	}
+	remoteBranchExists, err := doesRemoteBranchExist(theEffort.BranchName, commandDir)
+	if err != nil {
+		return "", fmt.Errorf("error, when doesRemoteBranchExist() for saveRepoWork(). Error: %w", err)
+	}
+	if remoteBranchExists {
+		remoteRef := "refs/remotes/origin/" + theEffort.BranchName
+		_, err = runGitCommand(commandDir, "fetch", "origin", fmt.Sprintf("+refs/heads/%s:%s", theEffort.BranchName, remoteRef))
+		if err != nil {
+			return "", fmt.Errorf("error, when fetching remote effort branch for saveRepoWork(). Error: %w", err)
+		}
+		refs = append(refs, remoteRef)
+	}
+	stashRef := getUncommittedWorkRef(theEffort.BranchName)
+	_, err = runGitCommand(commandDir, "rev-parse", "--verify", "--quiet", stashRef)
+	if err == nil {
+		refs = append(refs, stashRef)
+	}
+	if len(refs) == 0 {
+		return "no effort branch to save", nil
+	}
+
+	trunk, err := getTrunkBranch(r)
+	if err != nil {
+		return "", fmt.Errorf("error, when getTrunkBranch() for saveRepoWork(). Error: %w", err)
+	}
+	err = fetchTrunk(r, trunk)
+	if err != nil {
+		return "", fmt.Errorf("error, when fetchTrunk() for saveRepoWork(). Error: %w", err)
+	}
+	exclude := "^refs/remotes/origin/" + trunk
+	count, err := runGitCommand(commandDir, append(append([]string{"rev-list", "--count"}, refs...), exclude)...)
+	if err != nil {
+		return "", fmt.Errorf("error, when counting unmerged commits for saveRepoWork(). Error: %w", err)
+	}
+	if count == "0" {
+		return "nothing that isn't already in trunk", nil
+	}
+	bundle := recoveryDir + r.Title() + ".bundle"
+	_, err = runGitCommand(commandDir, append(append([]string{"bundle", "create", bundle}, refs...), exclude)...)
+	if err != nil {
+		return "", fmt.Errorf("error, when creating bundle for saveRepoWork(). Error: %w", err)
+	}
+	return fmt.Sprintf("saved %s commits to %s", count, bundle), nil
+}
+
+func formatRestoreInstructions(theEffort effort) string {
+	var restore strings.Builder
+	fmt.Fprintf(&restore, "Work saved before force deleting effort %s, branch %s.\n\n", theEffort.Name, theEffort.BranchName)
+	restore.WriteString("Each <repo>.bundle holds the commits that weren't in trunk, uncommitted changes were saved as a WIP commit.\n")
+	restore.WriteString("To get the branch back, run this in a clone of the repo:\n\n")
+	fmt.Fprintf(&restore, "    git fetch <repo>.bundle refs/heads/%s:refs/heads/%s\n\n", theEffort.BranchName, theEffort.BranchName)
+	fmt.Fprintf(&restore, "Use refs/remotes/origin/%s instead for what had been pushed, if the two differed.\n", theEffort.BranchName)
+	fmt.Fprintf(&restore, "Stashed uncommitted work is under %s, apply it with git stash apply.\n", getUncommittedWorkRef(theEffort.BranchName))
+	return restore.String()
+}
@@ -4,11 +4,14 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/sahilm/fuzzy"
 	"log"
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
 type repo struct {
@@ -17,6 +20,10 @@ type repo struct {
 	TrunkBranch string
 	Selected    bool
 	Visible     bool
+	// matchedIndexes are the runes of the title the edit effort filter matched
+	matchedIndexes []int
+	// matchRank orders the visible repos by how well they matched the edit effort filter, best first
+	matchRank int
 }
 
 func (r repo) Title() string {
@@ -30,6 +37,13 @@ func (r repo) Description() string { return r.Url }
 func (r repo) FilterValue() string { return r.Url }
 
 func addRepo(value string) (validationMsg string, err error) {
+	start := time.Now()
+	defer func() {
+		// rejected input never got as far as doing anything
+		if validationMsg == "" {
+			logEvent("add repo", value, start, err)
+		}
+	}()
 	if value == "" {
 		return "must provide a value", nil
 	}
@@ -39,7 +53,7 @@ func addRepo(value string) (validationMsg string, err error) {
 
 	err = cloneRepo(value)
 	if err != nil {
-		return "", fmt.Errorf("error, when cloneRepo() for addRepo(). Error: %v", err)
+		return "", fmt.Errorf("error, when cloneRepo() for addRepo(). Error: %w", err)
 	}
 
 	_, err = database.Exec(
@@ -47,7 +61,7 @@ func addRepo(value string) (validationMsg string, err error) {
 			VALUES (?)`,
 		value)
 	if err != nil {
-		return "", fmt.Errorf("error, when executing sql statement for addRepo(). Error: %v", err)
+		return "", fmt.Errorf("error, when executing sql statement for addRepo(). Error: %w", err)
 	}
 
 	return "", nil
@@ -62,15 +76,13 @@ func isRepoValid(url string) bool {
 func cloneRepo(url string) error {
 	err := os.MkdirAll(reposDirectory, 0755)
 	if err != nil {
-		return fmt.Errorf("error, when creating repos directory. Error: %v", err)
+		return fmt.Errorf("error, when creating repos directory. Error: %w", err)
 	}
 	_, err = os.Stat(getRepoDir(url))
 	if os.IsNotExist(err) {
-		cmd := exec.Command("git", "clone", "--bare", url)
-		cmd.Dir = reposDirectory
-		output, err := cmd.CombinedOutput()
+		output, err := runGitCombinedOutput(reposDirectory, "clone", "--bare", url)
 		if err != nil {
-			return fmt.Errorf("error, when executing clone commmand for %s. Output: %s. Error: %v", url, output, err)
+			return fmt.Errorf("error, when executing clone commmand for %s. Output: %s. Error: %w", url, output, err)
 		}
 	}
 	return nil
@@ -82,7 +94,7 @@ func getRepoDir(url string) string {
 
 func fetchRepos() ([]list.Item, error) {
 	rows, err := database.Query(
-		`SELECT id, url
+		`SELECT id, url, COALESCE(trunk_branch, '')
 		FROM repo r`,
 	)
 
@@ -98,7 +110,7 @@ func fetchRepos() ([]list.Item, error) {
 	}(rows)
 
 	if err != nil {
-		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %v", err)
+		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %w", err)
 	}
 
 	var result []repo
@@ -107,16 +119,17 @@ func fetchRepos() ([]list.Item, error) {
 		err = rows.Scan(
 			&r.Id,
 			&r.Url,
+			&r.TrunkBranch,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error, when scanning database rows. Error: %v", err)
+			return nil, fmt.Errorf("error, when scanning database rows. Error: %w", err)
 		}
 		result = append(result, r)
 	}
 
 	err = rows.Err()
 	if err != nil {
-		return nil, fmt.Errorf("error, when iterating through database rows. Error: %v", err)
+		return nil, fmt.Errorf("error, when iterating through database rows. Error: %w", err)
 	}
 
 	repos := make([]list.Item, len(result))
@@ -129,25 +142,64 @@ func fetchRepos() ([]list.Item, error) {
 	return repos, nil
 }
 
+// updateRepos carries the selections made in the visible list over to every repo and fuzzy matches the filter, ignoring
+// case, against the repo names
 func updateRepos(allRepos []list.Item, searchString string, filteredSelectionList []repo) []list.Item {
-	filteredListIndex := 0
+	// make selections made through filtered list apply to actual list
+	selections := make(map[int64]bool, len(filteredSelectionList))
+	for _, r := range filteredSelectionList {
+		selections[r.Id] = r.Selected
+	}
+	titles := make([]string, len(allRepos))
+	for i, r := range allRepos {
+		titles[i] = r.(repo).Title()
+	}
+	// best match first, repos that match equally well keep their order
+	found := fuzzy.FindNoSort(searchString, titles)
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Score > found[j].Score
+	})
+	matches := make(map[int]fuzzy.Match)
+	ranks := make(map[int]int)
+	for rank, match := range found {
+		matches[match.Index] = match
+		ranks[match.Index] = rank
+	}
 	for i, r := range allRepos {
 		theRepo := r.(repo)
-		// make selections made through filtered list apply to actual list
-		if len(filteredSelectionList) > filteredListIndex {
-			filterRepo := filteredSelectionList[filteredListIndex]
-			if filterRepo.Id == theRepo.Id {
-				theRepo.Selected = filterRepo.Selected
-				filteredListIndex++
-			}
+		selected, ok := selections[theRepo.Id]
+		if ok {
+			theRepo.Selected = selected
 		}
 		// set visible state based on filter value
-		theRepo.Visible = searchString == "" || strings.Contains(theRepo.Title(), searchString)
+		match, matched := matches[i]
+		theRepo.Visible = searchString == "" || matched
+		theRepo.matchedIndexes = nil
+		theRepo.matchRank = ranks[i]
+		if matched {
+			theRepo.matchedIndexes = byteToRuneIndexes(match.Str, match.MatchedIndexes)
+		}
 		allRepos[i] = theRepo
 	}
 	return allRepos
 }
 
+// byteToRuneIndexes converts the byte offsets fuzzy matches are reported in to the rune offsets lipgloss styles by
+func byteToRuneIndexes(str string, byteIndexes []int) []int {
+	runeIndexes := make(map[int]int, len(str))
+	runeIndex := 0
+	for byteIndex := range str {
+		runeIndexes[byteIndex] = runeIndex
+		runeIndex++
+	}
+	result := make([]int, len(byteIndexes))
+	for i, byteIndex := range byteIndexes {
+		result[i] = runeIndexes[byteIndex]
+	}
+	return result
+}
+
+// updateRepoVisibleSelectionList returns the repos that match the filter, best match first
 func updateRepoVisibleSelectionList(allRepos []list.Item) []repo {
 	var result []repo
 	for _, r := range allRepos {
@@ -159,28 +211,108 @@ func updateRepoVisibleSelectionList(allRepos []list.Item) []repo {
 	if len(result) == 0 {
 		return []repo{}
 	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].matchRank < result[j].matchRank
+	})
+	return result
+}
+
+// repoSelections is which repos are selected, it is what undoing a change to the selection goes back to
+func repoSelections(allRepos []list.Item) map[int64]bool {
+	result := make(map[int64]bool, len(allRepos))
+	for _, r := range allRepos {
+		theRepo := r.(repo)
+		result[theRepo.Id] = theRepo.Selected
+	}
 	return result
 }
 
+func applyRepoSelections(allRepos []list.Item, selections map[int64]bool) []list.Item {
+	for i, r := range allRepos {
+		theRepo := r.(repo)
+		theRepo.Selected = selections[theRepo.Id]
+		allRepos[i] = theRepo
+	}
+	return allRepos
+}
+
+func countSelectedRepos(allRepos []list.Item) int {
+	count := 0
+	for _, r := range allRepos {
+		if r.(repo).Selected {
+			count++
+		}
+	}
+	return count
+}
+
+func setReposSelected(repos []repo, selected bool) {
+	for i := range repos {
+		repos[i].Selected = selected
+	}
+}
+
+func invertRepoSelection(repos []repo) {
+	for i := range repos {
+		repos[i].Selected = !repos[i].Selected
+	}
+}
+
+// selectRepoRange selects the repos from the anchor to the cursor, the rest go back to how they were before the range
+// was started
+func selectRepoRange(repos []repo, before map[int64]bool, anchor int, cursor int) {
+	from, to := min(anchor, cursor), max(anchor, cursor)
+	for i := range repos {
+		repos[i].Selected = (i >= from && i <= to) || before[repos[i].Id]
+	}
+}
+
+// selectReposOfEffort adds the repos another effort uses to the selection, filtered out or not
+func selectReposOfEffort(allRepos []list.Item, other effort) []list.Item {
+	urls := make(map[string]bool, len(other.Repos))
+	for _, r := range other.Repos {
+		urls[r.Url] = true
+	}
+	for i, r := range allRepos {
+		theRepo := r.(repo)
+		if urls[theRepo.Url] {
+			theRepo.Selected = true
+			allRepos[i] = theRepo
+		}
+	}
+	return allRepos
+}
+
 func resetRepoSelection(repos []repo) []repo {
 	for i, r := range repos {
 		r.Visible = true
 		r.Selected = false
+		r.matchedIndexes = nil
+		r.matchRank = 0
 		repos[i] = r
 	}
 	return repos
 }
 
-func deleteRepo(theRepo repo) error {
-	err := isSafeToDeleteRepo(theRepo)
+func deleteRepo(theRepo repo) (err error) {
+	start := time.Now()
+	defer func() {
+		logEvent("delete repo", theRepo.Url, start, err)
+	}()
+	err = isSafeToDeleteRepo(theRepo)
+	if err != nil {
+		return fmt.Errorf("error, when isSafeToDeleteRepo() for deleteRepo(). Error: %w", err)
+	}
+
+	_, err = backupDatabase("pre_delete_repo")
 	if err != nil {
-		return fmt.Errorf("error, when isSafeToDeleteRepo() for deleteRepo(). Error: %v", err)
+		return fmt.Errorf("error, when backupDatabase() for deleteRepo(). Error: %w", err)
 	}
 
 	cmd := exec.Command("rm", "-rf", getRepoDir(theRepo.Url))
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("error, when attempting to delete repo files. Output: %s. Error: %v", output, err)
+		return fmt.Errorf("error, when attempting to delete repo files. Output: %s. Error: %w", output, err)
 	}
 
 	_, err = database.Exec(
@@ -189,7 +321,7 @@ func deleteRepo(theRepo repo) error {
 		theRepo.Id,
 	)
 	if err != nil {
-		return fmt.Errorf("error, when attempting to delete repo %s from database. Error: %v", theRepo.Title(), err)
+		return fmt.Errorf("error, when attempting to delete repo %s from database. Error: %w", theRepo.Title(), err)
 	}
 	return nil
 }
@@ -215,7 +347,7 @@ func isSafeToDeleteRepo(theRepo repo) error {
 	}(rows)
 
 	if err != nil {
-		return fmt.Errorf("error, when attempting to retrieve records. Error: %v", err)
+		return fmt.Errorf("error, when attempting to retrieve records. Error: %w", err)
 	}
 
 	var result []string
@@ -225,22 +357,18 @@ func isSafeToDeleteRepo(theRepo repo) error {
 			&r,
 		)
 		if err != nil {
-			return fmt.Errorf("error, when scanning database rows. Error: %v", err)
+			return fmt.Errorf("error, when scanning database rows. Error: %w", err)
 		}
 		result = append(result, r)
 	}
 
 	err = rows.Err()
 	if err != nil {
-		return fmt.Errorf("error, when iterating through database rows. Error: %v", err)
+		return fmt.Errorf("error, when iterating through database rows. Error: %w", err)
 	}
 
 	if len(result) != 0 {
-		return fmt.Errorf(
-			"unsafe delete operation, the %s repo is still being used by these efforts: %s",
-			theRepo.Url,
-			strings.Join(result, ", "),
-		)
+		return &repoInUseError{theRepo: theRepo, Efforts: result}
 	}
 	return nil
 }
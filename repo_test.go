@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func Test_updateRepos(t *testing.T) {
+	allRepos := []list.Item{
+		repo{Id: 1, Url: "git@example.com:team/web-app.git", Visible: true},
+		repo{Id: 2, Url: "git@example.com:team/billing-api.git", Visible: true},
+		repo{Id: 3, Url: "git@example.com:team/Billing.git", Visible: true},
+		repo{Id: 4, Url: "git@example.com:team/notifications.git", Visible: true},
+	}
+
+	// selecting billing-api while filtered must not move the selection to another repo
+	visible := updateRepoVisibleSelectionList(updateRepos(allRepos, "bil", nil))
+	var titles []string
+	for _, r := range visible {
+		titles = append(titles, r.Title())
+	}
+	if !reflect.DeepEqual(titles, []string{"Billing", "billing-api"}) {
+		t.Fatalf("got %v, but wanted the billing repos, best match first, ignoring case", titles)
+	}
+	if !reflect.DeepEqual(visible[0].matchedIndexes, []int{0, 1, 2}) {
+		t.Errorf("got matched runes %v, but wanted the first three", visible[0].matchedIndexes)
+	}
+	visible[1].Selected = true
+
+	// n, t and f are spread out over notifications
+	visible = updateRepoVisibleSelectionList(updateRepos(allRepos, "ntf", visible))
+	if len(visible) != 1 || visible[0].Id != 4 || !reflect.DeepEqual(visible[0].matchedIndexes, []int{0, 2, 4}) {
+		t.Fatalf("got %+v, but wanted notifications with every matched rune", visible)
+	}
+
+	visible = updateRepoVisibleSelectionList(updateRepos(allRepos, "", visible))
+	var selected []int64
+	for _, r := range visible {
+		if r.Selected {
+			selected = append(selected, r.Id)
+		}
+	}
+	if len(visible) != 4 || !reflect.DeepEqual(selected, []int64{2}) {
+		t.Errorf("got %d repos with %v selected, but wanted every repo with billing-api selected", len(visible), selected)
+	}
+}
+
+func Test_selectRepoRange(t *testing.T) {
+	repos := []repo{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}, {Id: 5}}
+	before := map[int64]bool{5: true}
+	// extending down from the second repo and then back up past it
+	selectRepoRange(repos, before, 1, 3)
+	if got := selectedIds(repos); !reflect.DeepEqual(got, []int64{2, 3, 4, 5}) {
+		t.Errorf("got %v selected, but wanted 2 through 4 along with 5 that was already selected", got)
+	}
+	selectRepoRange(repos, before, 1, 0)
+	if got := selectedIds(repos); !reflect.DeepEqual(got, []int64{1, 2, 5}) {
+		t.Errorf("got %v selected, but wanted the repos that left the range to go back to how they were", got)
+	}
+	invertRepoSelection(repos)
+	if got := selectedIds(repos); !reflect.DeepEqual(got, []int64{3, 4}) {
+		t.Errorf("got %v selected after inverting, but wanted 3 and 4", got)
+	}
+}
+
+func Test_selectReposOfEffort(t *testing.T) {
+	allRepos := []list.Item{
+		repo{Id: 1, Url: "git@example.com:team/web.git", Selected: true},
+		repo{Id: 2, Url: "git@example.com:team/billing.git"},
+		repo{Id: 3, Url: "git@example.com:team/auth.git"},
+	}
+	other := effort{Repos: []repo{{Url: "git@example.com:team/billing.git"}}}
+	before := repoSelections(allRepos)
+	allRepos = selectReposOfEffort(allRepos, other)
+	if countSelectedRepos(allRepos) != 2 || !allRepos[1].(repo).Selected {
+		t.Fatalf("got %+v, but wanted billing added to the selection", allRepos)
+	}
+	allRepos = applyRepoSelections(allRepos, before)
+	if countSelectedRepos(allRepos) != 1 || !allRepos[0].(repo).Selected {
+		t.Errorf("got %+v, but wanted only web selected after undoing", allRepos)
+	}
+}
+
+func selectedIds(repos []repo) []int64 {
+	var result []int64
+	for _, r := range repos {
+		if r.Selected {
+			result = append(result, r.Id)
+		}
+	}
+	return result
+}
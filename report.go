@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type repoOutcome string
+
+const (
+	repoOutcomeSucceeded  repoOutcome = "succeeded"
+	repoOutcomeConflicted repoOutcome = "conflicted"
+	repoOutcomeSkipped    repoOutcome = "skipped"
+	repoOutcomeFailed     repoOutcome = "failed"
+	repoOutcomeCancelled  repoOutcome = "cancelled"
+)
+
+// repoResult is the outcome of running an effort wide action against one of its repos
+type repoResult struct {
+	theRepo repo
+	Outcome repoOutcome
+	Detail  string
+	// Err is the cause when Outcome is failed, for callers that need more than the Detail text
+	Err error
+}
+
+func formatRepoResults(title string, results []repoResult) string {
+	width := 0
+	for _, r := range results {
+		width = max(width, len(r.theRepo.Title()))
+	}
+	lines := []string{title}
+	for _, r := range results {
+		line := fmt.Sprintf("%-*s  %-10s", width, r.theRepo.Title(), r.Outcome)
+		if r.Detail != "" {
+			line += "  " + r.Detail
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// countRepoOutcome is used to decide the exit code of commands that act on every repo of an effort
+func countRepoOutcome(results []repoResult, outcome repoOutcome) int {
+	count := 0
+	for _, r := range results {
+		if r.Outcome == outcome {
+			count++
+		}
+	}
+	return count
+}
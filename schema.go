@@ -1,14 +1,18 @@
 package main
 
 import (
+	"crypto/sha256"
 	"database/sql"
 	"embed"
+	"encoding/hex"
 	"fmt"
 	"io/fs"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
@@ -17,45 +21,65 @@ import (
 var DatabaseMigrationDirectory = "schema"
 var database *sql.DB
 
+// down migrations live next to their up migration, e.g., 02_add_thing.sql is undone by 02_add_thing.down.sql
+const downMigrationSuffix = ".down.sql"
+
+type appliedMigration struct {
+	FileName  string
+	Checksum  string
+	AppliedAt string
+}
+
+type migrationStatus struct {
+	FileName  string
+	Applied   bool
+	AppliedAt string
+	// Modified means the file has changed since it was applied
+	Modified bool
+	HasDown  bool
+}
+
 func ProcessSchemaChanges(databaseFiles embed.FS) error {
 	err := createInitTable()
 	if err != nil {
-		return fmt.Errorf("error occurred when attempting to create init table: %v", err)
+		return fmt.Errorf("error occurred when attempting to create init table: %w", err)
 	}
 
-	dirEntries, err := fs.ReadDir(databaseFiles, DatabaseMigrationDirectory)
+	migrationFiles, err := readMigrationFileNames(databaseFiles)
 	if err != nil {
-		return fmt.Errorf("an error has occurred when attempting to read database directory. Error: %v", err)
-	}
-	var migrationFileCandidateFileNames []string
-	for _, entry := range dirEntries {
-		if !entry.IsDir() {
-			migrationFileCandidateFileNames = append(migrationFileCandidateFileNames, entry.Name())
-		}
+		return fmt.Errorf("error occurred when reading migration files: %w", err)
 	}
-
-	migrationFiles := filterForMigrationFiles(migrationFileCandidateFileNames)
-	var migrationsCompleted []string
 	if len(migrationFiles) == 0 {
 		return nil
-	} else {
-		migrationsCompleted, err = checkForCompletedMigrations()
-		if err != nil {
-			return fmt.Errorf("error has occurred when checking for completed migrations: %v", err)
-		}
 	}
 
-	migrationsNeeded := determineMigrationsNeeded(migrationFiles, migrationsCompleted)
+	migrationsCompleted, err := checkForCompletedMigrations()
+	if err != nil {
+		return fmt.Errorf("error has occurred when checking for completed migrations: %w", err)
+	}
+
+	err = verifyMigrationChecksums(databaseFiles, migrationFiles, migrationsCompleted)
+	if err != nil {
+		return fmt.Errorf("error has occurred when verifying migration checksums: %w", err)
+	}
+
+	completedFileNames := make([]string, 0, len(migrationsCompleted))
+	for fileName := range migrationsCompleted {
+		completedFileNames = append(completedFileNames, fileName)
+	}
+	migrationsNeeded := determineMigrationsNeeded(migrationFiles, completedFileNames)
 	migrationsNeededSorted := sortMigrationsNeededFiles(migrationsNeeded)
-	for _, fileName := range migrationsNeededSorted {
-		filePath := fmt.Sprintf("%s/%s", DatabaseMigrationDirectory, fileName)
-		err = executeSQLFile(filePath, databaseFiles)
+	// nothing worth backing up until at least one migration has been applied
+	if len(migrationsNeededSorted) != 0 && len(migrationsCompleted) != 0 {
+		_, err = backupDatabase("pre_migration")
 		if err != nil {
-			return fmt.Errorf("error occurred when executing sql script: Filename: %s. Error: %v", fileName, err)
+			return fmt.Errorf("error has occurred when backing up the database before migrating: %w", err)
 		}
-		err = recordSuccessfulMigration(fileName)
+	}
+	for _, fileName := range migrationsNeededSorted {
+		err = applyMigration(fileName, databaseFiles)
 		if err != nil {
-			return fmt.Errorf("error has occurred when attempting to record a successful migration: %v", err)
+			return fmt.Errorf("error occurred when applying migration: Filename: %s. Error: %w", fileName, err)
 		}
 	}
 	return nil
@@ -66,15 +90,59 @@ func createInitTable() error {
 		(
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			migration_file_name TEXT NOT NULL
-				CONSTRAINT init_migration_file_name_uindex UNIQUE
+				CONSTRAINT init_migration_file_name_uindex UNIQUE,
+			checksum TEXT,
+			applied_at TEXT
 		);
 	`)
 	if err != nil {
-		return fmt.Errorf("error, when executing query to create init table: %v", err)
+		return fmt.Errorf("error, when executing query to create init table: %w", err)
+	}
+
+	// init tables created before checksums were tracked need the newer columns added
+	for _, column := range []string{"checksum", "applied_at"} {
+		var exists bool
+		exists, err = doesColumnExist("init", column)
+		if err != nil {
+			return fmt.Errorf("error, when doesColumnExist() for createInitTable(). Error: %w", err)
+		}
+		if !exists {
+			_, err = database.Exec(fmt.Sprintf("ALTER TABLE init ADD COLUMN %s TEXT", column))
+			if err != nil {
+				return fmt.Errorf("error, when adding column %s to init table: %w", column, err)
+			}
+		}
 	}
 	return nil
 }
 
+func doesColumnExist(table string, column string) (bool, error) {
+	var count int
+	err := database.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?",
+		table,
+		column,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("error, when checking table info for %s: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+func readMigrationFileNames(databaseFiles embed.FS) ([]string, error) {
+	dirEntries, err := fs.ReadDir(databaseFiles, DatabaseMigrationDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred when attempting to read database directory. Error: %w", err)
+	}
+	var migrationFileCandidateFileNames []string
+	for _, entry := range dirEntries {
+		if !entry.IsDir() {
+			migrationFileCandidateFileNames = append(migrationFileCandidateFileNames, entry.Name())
+		}
+	}
+	return filterForMigrationFiles(migrationFileCandidateFileNames), nil
+}
+
 func sortMigrationsNeededFiles(needed []string) []string {
 	re := regexp.MustCompile(`^(\d+)`)
 
@@ -104,74 +172,350 @@ func filterForMigrationFiles(candidates []string) []string {
 	var migrationFiles []string
 	re := regexp.MustCompile(`^\d+`)
 	for _, fileName := range candidates {
-		if re.MatchString(fileName) {
+		if re.MatchString(fileName) && !strings.HasSuffix(fileName, downMigrationSuffix) {
 			migrationFiles = append(migrationFiles, fileName)
 		}
 	}
 	return migrationFiles
 }
 
-func recordSuccessfulMigration(fileName string) error {
-	_, err := database.Exec(
-		"INSERT INTO init (migration_file_name)\nVALUES (?)",
+func getDownMigrationFileName(fileName string) string {
+	return strings.TrimSuffix(fileName, ".sql") + downMigrationSuffix
+}
+
+func calculateChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func readMigrationFile(fileName string, databaseFiles embed.FS) ([]byte, error) {
+	filePath := fmt.Sprintf("%s/%s", DatabaseMigrationDirectory, fileName)
+	content, err := databaseFiles.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SQL file: %w", err)
+	}
+	return content, nil
+}
+
+// verifyMigrationChecksums fails if an already applied migration has been edited since, migrations recorded before
+// checksums were tracked get their checksum backfilled
+func verifyMigrationChecksums(databaseFiles embed.FS, migrationFiles []string, migrationsCompleted map[string]appliedMigration) error {
+	for _, fileName := range migrationFiles {
+		completed, ok := migrationsCompleted[fileName]
+		if !ok {
+			continue
+		}
+		content, err := readMigrationFile(fileName, databaseFiles)
+		if err != nil {
+			return fmt.Errorf("error, when readMigrationFile() for verifyMigrationChecksums(). Error: %w", err)
+		}
+		checksum := calculateChecksum(content)
+		if completed.Checksum == "" {
+			_, err = database.Exec(
+				"UPDATE init\nSET checksum = ?\nWHERE migration_file_name = ?",
+				checksum,
+				fileName,
+			)
+			if err != nil {
+				return fmt.Errorf("error, when backfilling checksum for %s. Error: %w", fileName, err)
+			}
+			continue
+		}
+		if completed.Checksum != checksum {
+			return fmt.Errorf("migration %s has been modified after it was applied, add a new migration instead of editing an applied one", fileName)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs the migration and records it in the same transaction so a failure leaves nothing half applied
+func applyMigration(fileName string, databaseFiles embed.FS) (err error) {
+	content, err := readMigrationFile(fileName, databaseFiles)
+	if err != nil {
+		return fmt.Errorf("error, when readMigrationFile() for applyMigration(). Error: %w", err)
+	}
+
+	tx, err := database.Begin()
+	if err != nil {
+		return fmt.Errorf("error, when starting transaction for applyMigration(). Error: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				err = fmt.Errorf("%w. Rollback Error: %w", err, rollbackErr)
+			}
+		}
+	}()
+
+	err = executeSQLStatements(tx, string(content))
+	if err != nil {
+		return fmt.Errorf("error occurred when executing sql script. Error: %w", err)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO init (migration_file_name, checksum, applied_at)\nVALUES (?, ?, ?)",
 		fileName,
+		calculateChecksum(content),
+		time.Now().UTC().Format(time.RFC3339),
 	)
 	if err != nil {
-		return fmt.Errorf("error occurred when attempting to run sql command: %v", err)
+		return fmt.Errorf("error has occurred when attempting to record a successful migration: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error, when committing transaction for applyMigration(). Error: %w", err)
 	}
 	return nil
 }
 
-func checkForCompletedMigrations() (results []string, err error) {
-	rows, err := database.Query(
-		"SELECT migration_file_name\nFROM init",
-	)
+// rollbackLatestMigration undoes the most recently applied migration using its down migration file
+func rollbackLatestMigration(databaseFiles embed.FS) (fileName string, err error) {
+	err = database.QueryRow(
+		"SELECT migration_file_name\nFROM init\nORDER BY id DESC\nLIMIT 1",
+	).Scan(&fileName)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("there are no applied migrations to roll back")
+	}
+	if err != nil {
+		return "", fmt.Errorf("error, when finding latest migration for rollbackLatestMigration(). Error: %w", err)
+	}
+
+	downFileName := getDownMigrationFileName(fileName)
+	content, err := readMigrationFile(downFileName, databaseFiles)
+	if err != nil {
+		return "", fmt.Errorf("migration %s can not be rolled back because it has no %s file. Error: %w", fileName, downFileName, err)
+	}
+
+	_, err = backupDatabase("pre_rollback")
+	if err != nil {
+		return "", fmt.Errorf("error, when backupDatabase() for rollbackLatestMigration(). Error: %w", err)
+	}
+
+	tx, err := database.Begin()
+	if err != nil {
+		return "", fmt.Errorf("error, when starting transaction for rollbackLatestMigration(). Error: %w", err)
+	}
 	defer func() {
-		err = rows.Err()
 		if err != nil {
-			err = fmt.Errorf("error, occurred when reading rows. Error: %v", err)
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				err = fmt.Errorf("%w. Rollback Error: %w", err, rollbackErr)
+			}
 		}
-		rows.Close()
 	}()
 
+	err = executeSQLStatements(tx, string(content))
+	if err != nil {
+		return "", fmt.Errorf("error occurred when executing down migration %s. Error: %w", downFileName, err)
+	}
+	_, err = tx.Exec(
+		"DELETE FROM init\nWHERE migration_file_name = ?",
+		fileName,
+	)
+	if err != nil {
+		return "", fmt.Errorf("error, when removing migration record for rollbackLatestMigration(). Error: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return "", fmt.Errorf("error, when committing transaction for rollbackLatestMigration(). Error: %w", err)
+	}
+	return fileName, nil
+}
+
+func fetchMigrationStatuses(databaseFiles embed.FS) ([]migrationStatus, error) {
+	err := createInitTable()
+	if err != nil {
+		return nil, fmt.Errorf("error, when createInitTable() for fetchMigrationStatuses(). Error: %w", err)
+	}
+	migrationFiles, err := readMigrationFileNames(databaseFiles)
+	if err != nil {
+		return nil, fmt.Errorf("error, when readMigrationFileNames() for fetchMigrationStatuses(). Error: %w", err)
+	}
+	migrationsCompleted, err := checkForCompletedMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("error, when checkForCompletedMigrations() for fetchMigrationStatuses(). Error: %w", err)
+	}
+
+	var result []migrationStatus
+	for _, fileName := range sortMigrationsNeededFiles(migrationFiles) {
+		status := migrationStatus{FileName: fileName}
+		_, err = fs.Stat(databaseFiles, fmt.Sprintf("%s/%s", DatabaseMigrationDirectory, getDownMigrationFileName(fileName)))
+		status.HasDown = err == nil
+		completed, ok := migrationsCompleted[fileName]
+		if ok {
+			status.Applied = true
+			status.AppliedAt = completed.AppliedAt
+			var content []byte
+			content, err = readMigrationFile(fileName, databaseFiles)
+			if err != nil {
+				return nil, fmt.Errorf("error, when readMigrationFile() for fetchMigrationStatuses(). Error: %w", err)
+			}
+			status.Modified = completed.Checksum != "" && completed.Checksum != calculateChecksum(content)
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+func checkForCompletedMigrations() (results map[string]appliedMigration, err error) {
+	rows, err := database.Query(
+		"SELECT migration_file_name, COALESCE(checksum, ''), COALESCE(applied_at, '')\nFROM init",
+	)
 	if err != nil {
-		return nil, fmt.Errorf("error has occurred when attempting to retrieve pending migrations: %v", err)
+		return nil, fmt.Errorf("error has occurred when attempting to retrieve pending migrations: %w", err)
 	}
+	defer func() {
+		rowsErr := rows.Err()
+		if rowsErr != nil && err == nil {
+			err = fmt.Errorf("error, occurred when reading rows. Error: %w", rowsErr)
+		}
+		rows.Close()
+	}()
 
+	results = make(map[string]appliedMigration)
 	for rows.Next() {
-		var result string
+		var result appliedMigration
 		err = rows.Scan(
-			&result,
+			&result.FileName,
+			&result.Checksum,
+			&result.AppliedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error has occurred when scanning for pending migrations: %v", err)
+			return nil, fmt.Errorf("error has occurred when scanning for pending migrations: %w", err)
 		}
-		results = append(results, result)
+		results[result.FileName] = result
 	}
 
 	return results, nil
 }
 
-func executeSQLFile(filePath string, databaseFiles embed.FS) error {
-	content, err := databaseFiles.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read SQL file: %w", err)
+func executeSQLStatements(tx *sql.Tx, script string) error {
+	for _, query := range splitSQLStatements(script) {
+		_, err := tx.Exec(query)
+		if err != nil {
+			return fmt.Errorf("error, failed to execute QUERY: %s. ERROR: %w", query, err)
+		}
 	}
+	return nil
+}
 
-	sql := string(content)
-	queries := strings.Split(sql, ";")
+// splitSQLStatements splits a script on semicolons while ignoring the ones inside of quotes, comments and
+// BEGIN...END blocks (e.g., trigger bodies)
+func splitSQLStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var word strings.Builder
+	// blockDepth tracks nesting of BEGIN...END and CASE...END since their bodies can contain semicolons
+	blockDepth := 0
+	inTrigger := false
 
-	for _, query := range queries {
-		query = strings.TrimSpace(query)
-		if query == "" {
-			continue
+	endWord := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := strings.ToUpper(word.String())
+		word.Reset()
+		switch w {
+		case "TRIGGER":
+			inTrigger = true
+		case "BEGIN":
+			if inTrigger {
+				blockDepth++
+			}
+		case "CASE":
+			blockDepth++
+		case "END":
+			if blockDepth > 0 {
+				blockDepth--
+			}
 		}
+	}
 
-		_, err = database.Exec(query)
-		if err != nil {
-			return fmt.Errorf("error, failed to execute QUERY: %s. ERROR: %v", query, err)
+	endStatement := func() {
+		statement := strings.TrimSpace(current.String())
+		if statement != "" {
+			statements = append(statements, statement)
 		}
+		current.Reset()
+		inTrigger = false
+		blockDepth = 0
 	}
 
-	return nil
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			endWord()
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			current.WriteRune(c)
+			for i++; i < len(runes); i++ {
+				current.WriteRune(runes[i])
+				if runes[i] == closing {
+					// a doubled quote is an escaped quote, not the end of the literal
+					if closing != ']' && i+1 < len(runes) && runes[i+1] == closing {
+						i++
+						current.WriteRune(runes[i])
+						continue
+					}
+					break
+				}
+			}
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			endWord()
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+				current.WriteRune(runes[i])
+			}
+			if i < len(runes) {
+				current.WriteRune(runes[i])
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			endWord()
+			current.WriteString("/*")
+			// the closing */ can't share the * of the opening /*, so /*/ doesn't end the comment
+			bodyStart := i + 2
+			for i = bodyStart; i < len(runes); i++ {
+				current.WriteRune(runes[i])
+				if runes[i] == '/' && i > bodyStart && runes[i-1] == '*' {
+					break
+				}
+			}
+		case c == ';':
+			endWord()
+			if blockDepth > 0 {
+				current.WriteRune(c)
+			} else {
+				endStatement()
+			}
+		case unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_':
+			word.WriteRune(c)
+			current.WriteRune(c)
+		default:
+			endWord()
+			current.WriteRune(c)
+		}
+	}
+	endWord()
+	endStatement()
+
+	// drop statements that only contain comments
+	var result []string
+	for _, statement := range statements {
+		if hasSQL(statement) {
+			result = append(result, statement)
+		}
+	}
+	return result
+}
+
+var sqlCommentRegex = regexp.MustCompile(`(?s)/\*.*?\*/|--[^\n]*`)
+
+func hasSQL(statement string) bool {
+	return strings.TrimSpace(sqlCommentRegex.ReplaceAllString(statement, "")) != ""
 }
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS operation_step;
+DROP TABLE IF EXISTS operation;
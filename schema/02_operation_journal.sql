@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS operation (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	effort_id INTEGER,
+	effort_name TEXT,
+	branch_name TEXT,
+	force INTEGER NOT NULL DEFAULT 0,
+	recovery_dir TEXT,
+	pid INTEGER,
+	status TEXT NOT NULL,
+	started_at TEXT NOT NULL,
+	finished_at TEXT);
+
+CREATE TABLE IF NOT EXISTS operation_step (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	operation_id INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	repo_id INTEGER,
+	repo_url TEXT,
+	status TEXT NOT NULL,
+	detail TEXT,
+	updated_at TEXT,
+	FOREIGN KEY (operation_id) REFERENCES operation(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_operation_status ON operation (status);
+CREATE INDEX idx_operation_step_operation_id ON operation_step (operation_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS operation_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	action TEXT NOT NULL,
+	subject TEXT,
+	command TEXT,
+	exit_code INTEGER,
+	duration_ms INTEGER,
+	output TEXT);
+
+CREATE INDEX idx_operation_log_created_at ON operation_log (created_at);
+CREATE INDEX idx_operation_log_kind ON operation_log (kind);
@@ -0,0 +1,3 @@
+ALTER TABLE effort DROP COLUMN last_opened_at;
+ALTER TABLE effort DROP COLUMN updated_at;
+ALTER TABLE effort DROP COLUMN created_at;
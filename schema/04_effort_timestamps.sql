@@ -0,0 +1,4 @@
+-- efforts created before this migration are left without timestamps, so they sort as the oldest until they are next used
+ALTER TABLE effort ADD COLUMN created_at TEXT;
+ALTER TABLE effort ADD COLUMN updated_at TEXT;
+ALTER TABLE effort ADD COLUMN last_opened_at TEXT;
@@ -0,0 +1,2 @@
+-- whether a step had anything to do when it started, steps journaled before this migration are assumed to have had
+ALTER TABLE operation_step ADD COLUMN changes INTEGER NOT NULL DEFAULT 1;
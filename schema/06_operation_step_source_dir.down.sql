@@ -0,0 +1 @@
+ALTER TABLE operation_step DROP COLUMN source_dir;
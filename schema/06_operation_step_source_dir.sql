@@ -0,0 +1,2 @@
+-- where an adopted worktree was before it was moved into the effort directory, rolling back moves it back there
+ALTER TABLE operation_step ADD COLUMN source_dir TEXT;
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_splitSQLStatements(t *testing.T) {
+	script := `-- leading comment; with a semicolon
+CREATE TABLE a (id INTEGER, note TEXT DEFAULT 'x;y');
+INSERT INTO a (note) VALUES ('it''s; fine');
+/*/ starts; like an end */
+/* block; comment */
+CREATE TRIGGER a_trigger AFTER INSERT ON a
+BEGIN
+	UPDATE a SET note = CASE WHEN note = '' THEN 'empty' ELSE note END WHERE id = NEW.id;
+	DELETE FROM a WHERE id < 0;
+END;
+-- trailing comment`
+	got := splitSQLStatements(script)
+	expected := []string{
+		"-- leading comment; with a semicolon\nCREATE TABLE a (id INTEGER, note TEXT DEFAULT 'x;y')",
+		"INSERT INTO a (note) VALUES ('it''s; fine')",
+		"/*/ starts; like an end */\n/* block; comment */\nCREATE TRIGGER a_trigger AFTER INSERT ON a\nBEGIN\n\tUPDATE a SET note = CASE WHEN note = '' THEN 'empty' ELSE note END WHERE id = NEW.id;\n\tDELETE FROM a WHERE id < 0;\nEND",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q, but wanted %q", got, expected)
+	}
+}
+
+func Test_filterForMigrationFiles(t *testing.T) {
+	got := filterForMigrationFiles([]string{"01_init.sql", "02_thing.sql", "02_thing.down.sql", "README.md"})
+	expected := []string{"01_init.sql", "02_thing.sql"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, but wanted %v", got, expected)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type searchMatch struct {
+	theRepo     repo
+	WorktreeDir string
+	File        string
+	Line        int
+	Text        string
+}
+
+func (s searchMatch) path() string {
+	return s.WorktreeDir + "/" + s.File
+}
+
+// searchEffort runs git grep in every worktree of the effort, matches are ordered by repo then file
+func searchEffort(theEffort effort, pattern string, ignoreCase bool) ([]searchMatch, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("must provide a search pattern")
+	}
+	effortRepos, err := fetchReposForEffort(theEffort.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposForEffort() for searchEffort(). Error: %w", err)
+	}
+	matches := make([][]searchMatch, len(effortRepos))
+	errs := make([]error, len(effortRepos))
+	runWorkerPool(len(effortRepos), config.GitConcurrency, func(i int) {
+		matches[i], errs[i] = searchRepo(theEffort, effortRepos[i], pattern, ignoreCase)
+	})
+	var result []searchMatch
+	for i := range effortRepos {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		result = append(result, matches[i]...)
+	}
+	return result, nil
+}
+
+func searchRepo(theEffort effort, r repo, pattern string, ignoreCase bool) ([]searchMatch, error) {
+	worktreeDir := getWorktreeDir(theEffort, r)
+	exists, err := checkDirectoryExists(worktreeDir)
+	if err != nil {
+		return nil, fmt.Errorf("error, when checkDirectoryExists() for searchRepo(). Error: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	matches, err := grepWorktree(worktreeDir, pattern, ignoreCase)
+	if err != nil {
+		return nil, fmt.Errorf("error, when grepWorktree() for searchRepo() of repo: %s. Error: %w", r.Title(), err)
+	}
+	for i := range matches {
+		matches[i].theRepo = r
+	}
+	return matches, nil
+}
+
+func grepWorktree(worktreeDir string, pattern string, ignoreCase bool) ([]searchMatch, error) {
+	// -z separates the file name and line number with a null byte so file names containing colons parse correctly
+	args := []string{"grep", "-n", "-z", "-I", "--no-color"}
+	if ignoreCase {
+		args = append(args, "-i")
+	}
+	args = append(args, "-e", pattern)
+	ctx, cancel := newGitContext(args)
+	defer cancel()
+	start := time.Now()
+	cmd := newGitCommand(ctx, worktreeDir, args...)
+	output, err := cmd.Output()
+	logGitCommand(worktreeDir, args, start, cmd, output, err)
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("error, when running git grep at directory: %s. Error: %w", worktreeDir, gitContextError(ctx, args))
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		// git grep exits with 1 when nothing matched
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error, when running git grep at directory: %s. Error: %w", worktreeDir, err)
+	}
+	return parseGrepOutput(worktreeDir, string(output)), nil
+}
+
+func parseGrepOutput(worktreeDir string, output string) []searchMatch {
+	var result []searchMatch
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, "\x00", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		lineNumber, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		result = append(result, searchMatch{
+			WorktreeDir: worktreeDir,
+			File:        parts[0],
+			Line:        lineNumber,
+			Text:        parts[2],
+		})
+	}
+	return result
+}
+
+// formatSearchMatches groups the matches by repo then file
+func formatSearchMatches(matches []searchMatch) string {
+	var lines []string
+	var currentRepo, currentFile string
+	for _, match := range matches {
+		if match.theRepo.Title() != currentRepo {
+			currentRepo = match.theRepo.Title()
+			currentFile = ""
+			lines = append(lines, currentRepo)
+		}
+		if match.File != currentFile {
+			currentFile = match.File
+			lines = append(lines, "  "+currentFile)
+		}
+		lines = append(lines, fmt.Sprintf("    %d: %s", match.Line, strings.TrimSpace(match.Text)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shellCommand opens $SHELL in dir so the user can sort out whatever is blocking an operation
+func shellCommand(dir string) *exec.Cmd {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+	cmd := exec.Command(shell)
+	cmd.Dir = dir
+	return cmd
+}
+
+// editorCommand opens the match in $EDITOR, the +line argument is understood by vi, vim, nvim, nano, emacs and helix
+func editorCommand(match searchMatch) *exec.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, fmt.Sprintf("+%d", match.Line), match.path())
+	cmd.Dir = match.WorktreeDir
+	return cmd
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_parseGrepOutput(t *testing.T) {
+	output := "a.go\x0012\x00\tfoo := bar\n" +
+		"dir/with:colon.txt\x003\x00foo: 1\n" +
+		"notes.md\x007\x00\n" +
+		"broken line\n"
+	expected := []searchMatch{
+		{WorktreeDir: "/w", File: "a.go", Line: 12, Text: "\tfoo := bar"},
+		{WorktreeDir: "/w", File: "dir/with:colon.txt", Line: 3, Text: "foo: 1"},
+		{WorktreeDir: "/w", File: "notes.md", Line: 7, Text: ""},
+	}
+	got := parseGrepOutput("/w", output)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v, but wanted %+v", got, expected)
+	}
+}
+
+func Test_grepWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		_, err := runGitCommand(dir, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-b", "main")
+	files := map[string]string{
+		"a.go":         "package a\n\nvar Needle = 1\n",
+		"b:c.txt":      "first\nneedle: second\n",
+		"untouched.md": "nothing here\n",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("add", ".")
+
+	got, err := grepWorktree(dir, "needle", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []searchMatch{
+		{WorktreeDir: dir, File: "a.go", Line: 3, Text: "var Needle = 1"},
+		{WorktreeDir: dir, File: "b:c.txt", Line: 2, Text: "needle: second"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v, but wanted %+v", got, expected)
+	}
+
+	got, err = grepWorktree(dir, "Needle", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].File != "a.go" {
+		t.Errorf("got %+v, but wanted only the case sensitive match in a.go", got)
+	}
+
+	// nothing matching is not an error
+	got, err = grepWorktree(dir, "haystack", false)
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %+v and error %v, but wanted no matches", got, err)
+	}
+}
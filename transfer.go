@@ -0,0 +1,379 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+const exportFormatVersion = 1
+
+// exportedState is the portable representation of everything the tool tracks, it is used to move to a new machine
+type exportedState struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exportedAt"`
+	Repos      []exportedRepo   `json:"repos"`
+	Efforts    []exportedEffort `json:"efforts"`
+}
+
+type exportedRepo struct {
+	Url         string `json:"url"`
+	TrunkBranch string `json:"trunkBranch,omitempty"`
+}
+
+type exportedEffort struct {
+	Name        string `json:"name"`
+	BranchName  string `json:"branchName"`
+	Description string `json:"description"`
+	// RepoUrls are used instead of ids since ids are not stable across machines
+	RepoUrls []string `json:"repoUrls"`
+	// the timestamps are left out when they aren't known, e.g., for efforts created before they were tracked
+	CreatedAt    *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty"`
+	LastOpenedAt *time.Time `json:"lastOpenedAt,omitempty"`
+}
+
+func exportState(w io.Writer) error {
+	repoItems, err := fetchRepos()
+	if err != nil {
+		return fmt.Errorf("error, when fetchRepos() for exportState(). Error: %w", err)
+	}
+	effortItems, err := fetchEfforts()
+	if err != nil {
+		return fmt.Errorf("error, when fetchEfforts() for exportState(). Error: %w", err)
+	}
+	effortRepoUrls, err := fetchEffortRepoUrls()
+	if err != nil {
+		return fmt.Errorf("error, when fetchEffortRepoUrls() for exportState(). Error: %w", err)
+	}
+
+	state := exportedState{
+		Version:    exportFormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Repos:      []exportedRepo{},
+		Efforts:    []exportedEffort{},
+	}
+	for _, item := range repoItems {
+		r := item.(repo)
+		state.Repos = append(state.Repos, exportedRepo{
+			Url:         r.Url,
+			TrunkBranch: r.TrunkBranch,
+		})
+	}
+	for _, item := range effortItems {
+		e := item.(effort)
+		urls := effortRepoUrls[e.Id]
+		if urls == nil {
+			urls = []string{}
+		}
+		state.Efforts = append(state.Efforts, exportedEffort{
+			Name:         e.Name,
+			BranchName:   e.BranchName,
+			Description:  e.Desc,
+			RepoUrls:     urls,
+			CreatedAt:    exportedTimestamp(e.CreatedAt),
+			UpdatedAt:    exportedTimestamp(e.UpdatedAt),
+			LastOpenedAt: exportedTimestamp(e.LastOpenedAt),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(state)
+	if err != nil {
+		return fmt.Errorf("error, when encoding export for exportState(). Error: %w", err)
+	}
+	return nil
+}
+
+func exportedTimestamp(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// fetchEffortRepoUrls returns the repo urls of each effort keyed by effort id
+func fetchEffortRepoUrls() (map[int64][]string, error) {
+	rows, err := database.Query(
+		`SELECT er.effort_id, r.url
+		FROM effort_repo er
+		JOIN repo r ON r.id = er.repo_id
+		ORDER BY r.url`,
+	)
+
+	defer func(rows *sql.Rows) {
+		if rows != nil {
+			closeRowsError := rows.Close()
+			if closeRowsError != nil {
+				// no choice but to log the error since defer doesn't let us return errors
+				// defer is needed though because it ensures a cleanup attempt is made even if we should return early due to an error
+				log.Printf("error, when attempting to close database rows: %v", closeRowsError)
+			}
+		}
+	}(rows)
+
+	if err != nil {
+		return nil, fmt.Errorf("error, when attempting to retrieve records. Error: %w", err)
+	}
+
+	result := make(map[int64][]string)
+	for rows.Next() {
+		var effortId int64
+		var url string
+		err = rows.Scan(
+			&effortId,
+			&url,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error, when scanning database rows. Error: %w", err)
+		}
+		result[effortId] = append(result[effortId], url)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error, when iterating through database rows. Error: %w", err)
+	}
+	return result, nil
+}
+
+// importState checks the whole export before changing anything and writes it in one transaction, so a failed import
+// leaves the database as it was. Worktrees are only created when asked for since old efforts may never be opened again.
+func importState(r io.Reader, createWorktrees bool) error {
+	var state exportedState
+	err := json.NewDecoder(r).Decode(&state)
+	if err != nil {
+		return fmt.Errorf("error, when decoding import for importState(). Error: %w", err)
+	}
+	if state.Version != exportFormatVersion {
+		return fmt.Errorf("error, unsupported export version %d, expected %d", state.Version, exportFormatVersion)
+	}
+
+	existingRepos, err := fetchRepos()
+	if err != nil {
+		return fmt.Errorf("error, when fetchRepos() for importState(). Error: %w", err)
+	}
+	existingUrls := make(map[string]bool)
+	for _, item := range existingRepos {
+		existingUrls[item.(repo).Url] = true
+	}
+	existingEfforts, err := fetchEfforts()
+	if err != nil {
+		return fmt.Errorf("error, when fetchEfforts() for importState(). Error: %w", err)
+	}
+	existingEffortBranches := make(map[string]string)
+	for _, item := range existingEfforts {
+		existingEffortBranches[item.(effort).Name] = item.(effort).BranchName
+	}
+	err = validateImport(state, existingUrls, existingEffortBranches)
+	if err != nil {
+		return fmt.Errorf("error, when validateImport() for importState(). Error: %w", err)
+	}
+
+	// the clones are the slow part so they run in the worker pool, the database is only written once they all succeeded
+	var toClone []repo
+	for _, theRepo := range state.Repos {
+		if !existingUrls[theRepo.Url] {
+			toClone = append(toClone, repo{Url: theRepo.Url})
+		}
+	}
+	err = repoResultsError(runRepoTasks(toClone, func(r repo) (string, error) {
+		return "", cloneRepo(r.Url)
+	}))
+	if err != nil {
+		return fmt.Errorf("error, when cloneRepo() for importState(). Error: %w", err)
+	}
+
+	// backed up before the database is written like every other change that can't be undone from the tool
+	_, err = backupDatabase("pre_import")
+	if err != nil {
+		return fmt.Errorf("error, when backupDatabase() for importState(). Error: %w", err)
+	}
+	tx, err := database.Begin()
+	if err != nil {
+		return fmt.Errorf("error, when starting transaction for importState(). Error: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, theRepo := range state.Repos {
+		if !existingUrls[theRepo.Url] {
+			_, err = tx.Exec(`INSERT INTO repo (url) VALUES (?)`, theRepo.Url)
+			if err != nil {
+				return fmt.Errorf("error, when inserting repo for importState(). Error: %w", err)
+			}
+		}
+		if theRepo.TrunkBranch != "" {
+			_, err = tx.Exec(
+				`UPDATE repo
+				SET trunk_branch = ?
+				WHERE url = ?`,
+				theRepo.TrunkBranch,
+				theRepo.Url,
+			)
+			if err != nil {
+				return fmt.Errorf("error, when updating trunk branch for importState(). Error: %w", err)
+			}
+		}
+	}
+
+	var added []exportedEffort
+	for _, theEffort := range state.Efforts {
+		if _, ok := existingEffortBranches[theEffort.Name]; !ok {
+			now := time.Now().UTC().Format(time.RFC3339)
+			_, err = tx.Exec(
+				`INSERT INTO effort (name, branch_name, description, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?)`,
+				theEffort.Name,
+				theEffort.BranchName,
+				theEffort.Description,
+				now,
+				now,
+			)
+			if err != nil {
+				return fmt.Errorf("error, when inserting effort %s for importState(). Error: %w", theEffort.Name, err)
+			}
+			added = append(added, theEffort)
+		}
+		for _, url := range theEffort.RepoUrls {
+			_, err = tx.Exec(
+				`INSERT OR IGNORE INTO effort_repo (effort_id, repo_id)
+				SELECT e.id, r.id
+				FROM effort e, repo r
+				WHERE e.name = ? AND r.url = ?`,
+				theEffort.Name,
+				url,
+			)
+			if err != nil {
+				return fmt.Errorf("error, when inserting repos of effort %s for importState(). Error: %w", theEffort.Name, err)
+			}
+		}
+		err = restoreEffortTimestamps(tx, theEffort)
+		if err != nil {
+			return fmt.Errorf("error, when restoreEffortTimestamps() for importState(). Error: %w", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error, when committing transaction for importState(). Error: %w", err)
+	}
+
+	for _, theEffort := range added {
+		effortDir, err := getEffortDir(theEffort.Name)
+		if err != nil {
+			return fmt.Errorf("error, when getEffortDir() for importState(). Error: %w", err)
+		}
+		err = os.MkdirAll(effortDir, 0755)
+		if err != nil {
+			return fmt.Errorf("error, when creating effort directory for importState(). Error: %w", err)
+		}
+	}
+	if createWorktrees {
+		err = createImportedWorktrees(state.Efforts)
+		if err != nil {
+			return fmt.Errorf("error, when createImportedWorktrees() for importState(). Error: %w", err)
+		}
+	}
+	return nil
+}
+
+// createImportedWorktrees adds the worktrees of every imported effort, the efforts are already saved so a failure
+// here can be finished by applying the repo selection of the effort
+func createImportedWorktrees(imported []exportedEffort) error {
+	repoItems, err := fetchRepos()
+	if err != nil {
+		return fmt.Errorf("error, when fetchRepos() for createImportedWorktrees(). Error: %w", err)
+	}
+	reposByUrl := make(map[string]repo)
+	for _, item := range repoItems {
+		theRepo := item.(repo)
+		reposByUrl[theRepo.Url] = theRepo
+	}
+	for _, theEffort := range imported {
+		e, err := fetchEffortByBranchName(theEffort.BranchName)
+		if err != nil {
+			return fmt.Errorf("error, when fetchEffortByBranchName() for createImportedWorktrees(). Error: %w", err)
+		}
+		selected := make([]repo, len(theEffort.RepoUrls))
+		for i, url := range theEffort.RepoUrls {
+			selected[i] = reposByUrl[url]
+		}
+		err = repoResultsError(runRepoTasks(selected, func(r repo) (string, error) {
+			return "", createWorktree(e, r)
+		}))
+		if err != nil {
+			return fmt.Errorf("error, when createWorktree() for createImportedWorktrees() of effort: %s. Error: %w", e.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateImport checks the whole import before anything is cloned or written. An effort that is already here, by both
+// name and branch, only gets the repos of the import, one that shares just its name or branch with an effort here fails
+// the import.
+func validateImport(state exportedState, existingUrls map[string]bool, existingEffortBranches map[string]string) error {
+	urls := make(map[string]bool)
+	for url := range existingUrls {
+		urls[url] = true
+	}
+	for _, theRepo := range state.Repos {
+		if !existingUrls[theRepo.Url] && !isRepoValid(theRepo.Url) {
+			return fmt.Errorf("error, invalid repo in import: %s", theRepo.Url)
+		}
+		urls[theRepo.Url] = true
+	}
+	effortsByBranch := make(map[string]string)
+	for name, branchName := range existingEffortBranches {
+		effortsByBranch[branchName] = name
+	}
+	for _, theEffort := range state.Efforts {
+		if theEffort.Name == "" || theEffort.BranchName == "" {
+			return fmt.Errorf("error, effort %q in import must have a name and a branch", theEffort.Name)
+		}
+		if branchName, ok := existingEffortBranches[theEffort.Name]; ok && branchName != theEffort.BranchName {
+			return fmt.Errorf("error, effort %s in import uses branch %s but the effort here uses %s", theEffort.Name, theEffort.BranchName, branchName)
+		}
+		if name, ok := effortsByBranch[theEffort.BranchName]; ok && name != theEffort.Name {
+			return fmt.Errorf("error, branch %s of effort %s in import is used by effort %s", theEffort.BranchName, theEffort.Name, name)
+		}
+		effortsByBranch[theEffort.BranchName] = theEffort.Name
+		for _, url := range theEffort.RepoUrls {
+			if !urls[url] {
+				return fmt.Errorf("error, effort %s references repo %s which is not part of the import", theEffort.Name, url)
+			}
+		}
+	}
+	return nil
+}
+
+// restoreEffortTimestamps keeps the recency of an effort across a move to another machine, a timestamp missing from the
+// import leaves the one the effort has
+func restoreEffortTimestamps(tx *sql.Tx, theEffort exportedEffort) error {
+	columns := []struct {
+		name  string
+		value *time.Time
+	}{
+		{"created_at", theEffort.CreatedAt},
+		{"updated_at", theEffort.UpdatedAt},
+		{"last_opened_at", theEffort.LastOpenedAt},
+	}
+	for _, column := range columns {
+		if column.value == nil {
+			continue
+		}
+		_, err := tx.Exec(
+			fmt.Sprintf(`UPDATE effort SET %s = ? WHERE name = ?`, column.name),
+			column.value.UTC().Format(time.RFC3339),
+			theEffort.Name,
+		)
+		if err != nil {
+			return fmt.Errorf("error, when setting %s for restoreEffortTimestamps(). Error: %w", column.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_importState_keepsEffortTimestamps(t *testing.T) {
+	openTestDatabase(t)
+	t.Setenv("HOME", t.TempDir())
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	_, err := database.Exec(
+		`INSERT INTO effort (name, branch_name, description, created_at, updated_at) VALUES ('billing', 'ABC-1', 'billing', ?, ?)`,
+		created.Format(time.RFC3339),
+		updated.Format(time.RFC3339),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported bytes.Buffer
+	err = exportState(&exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an empty database stands in for the new machine
+	_, err = database.Exec(`DELETE FROM effort`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = importState(&exported, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := findEffort("billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(updated) || !got.LastOpenedAt.IsZero() {
+		t.Errorf("got created %v, updated %v and opened %v, but wanted %v, %v and never", got.CreatedAt, got.UpdatedAt, got.LastOpenedAt, created, updated)
+	}
+}
+
+func Test_importState_keepsExportedName(t *testing.T) {
+	openTestDatabase(t)
+	t.Setenv("HOME", t.TempDir())
+
+	// the name differs from what addEffort would work out from the description
+	input := `{"version": 1, "repos": [], "efforts": [{"name": "billing", "branchName": "ABC-1", "description": "Billing rework", "repoUrls": []}]}`
+	err := importState(strings.NewReader(input), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := findEffort("billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.BranchName != "ABC-1" || got.Desc != "Billing rework" {
+		t.Errorf("got branch %q and description %q, but wanted ABC-1 and Billing rework", got.BranchName, got.Desc)
+	}
+}
+
+func Test_importState_conflictWritesNothing(t *testing.T) {
+	openTestDatabase(t)
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := database.Exec(`INSERT INTO effort (name, branch_name, description) VALUES ('billing', 'ABC-1', 'billing')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `{"version": 1, "repos": [], "efforts": [
+		{"name": "search", "branchName": "ABC-2", "description": "search", "repoUrls": []},
+		{"name": "billing", "branchName": "ABC-3", "description": "billing", "repoUrls": []}
+	]}`
+	err = importState(strings.NewReader(input), false)
+	if err == nil {
+		t.Fatal("got no error, but wanted the name taken by another branch to fail the import")
+	}
+	var count int
+	err = database.QueryRow(`SELECT COUNT(*) FROM effort`).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %d efforts, but wanted only the one that was already here", count)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// updateEffortFromTrunk brings the branch of every worktree in the effort up to date with its repo's trunk. A repo
+// that conflicts has the rebase or merge aborted so it is left as it was and the remaining repos still get updated.
+func updateEffortFromTrunk(theEffort effort, strategy string) ([]repoResult, error) {
+	effortRepos, err := fetchReposForEffort(theEffort.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchReposForEffort() for updateEffortFromTrunk(). Error: %w", err)
+	}
+	results := make([]repoResult, len(effortRepos))
+	runWorkerPool(len(effortRepos), config.GitConcurrency, func(i int) {
+		results[i] = markIfCancelled(updateRepoFromTrunk(theEffort, effortRepos[i], strategy))
+	})
+	markEffortUpdatedAfter(theEffort, results)
+	return results, nil
+}
+
+func updateRepoFromTrunk(theEffort effort, r repo, strategy string) repoResult {
+	result := repoResult{theRepo: r}
+	worktreeDir := getWorktreeDir(theEffort, r)
+	exists, err := checkDirectoryExists(worktreeDir)
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+	if !exists {
+		result.Outcome = repoOutcomeSkipped
+		result.Detail = "worktree is missing"
+		return result
+	}
+
+	dirty, err := isWorktreeDirty(worktreeDir)
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+	if dirty {
+		result.Outcome = repoOutcomeSkipped
+		result.Detail = "worktree has uncommitted changes"
+		return result
+	}
+
+	trunk, err := getTrunkBranch(r)
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+	err = fetchTrunk(r, trunk)
+	if err != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+
+	remoteTrunk := "origin/" + trunk
+	args := []string{"rebase", remoteTrunk}
+	if strategy == trunkUpdateStrategyMerge {
+		args = []string{"merge", "--no-edit", remoteTrunk}
+	}
+	_, err = runGitCommand(worktreeDir, args...)
+	if err == nil {
+		result.Outcome = repoOutcomeSucceeded
+		if strategy == trunkUpdateStrategyMerge {
+			result.Detail = "merged " + remoteTrunk
+		} else {
+			result.Detail = "rebased onto " + remoteTrunk
+		}
+		return result
+	}
+
+	conflicts, conflictsErr := runGitCommand(worktreeDir, "diff", "--name-only", "--diff-filter=U")
+	if conflictsErr != nil || conflicts == "" {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = err.Error()
+		return result
+	}
+	_, abortErr := runGitCommand(worktreeDir, strategy, "--abort")
+	if abortErr != nil {
+		result.Outcome = repoOutcomeFailed
+		result.Detail = fmt.Sprintf("conflicts in %s and the %s could not be aborted: %v", strings.ReplaceAll(conflicts, "\n", ", "), strategy, abortErr)
+		return result
+	}
+	result.Outcome = repoOutcomeConflicted
+	result.Detail = fmt.Sprintf(
+		"conflicts in %s, the %s was aborted so the worktree is unchanged. Resolve with: git %s",
+		strings.ReplaceAll(conflicts, "\n", ", "),
+		strategy,
+		strings.Join(args, " "),
+	)
+	return result
+}
+
+func isWorktreeDirty(worktreeDir string) (bool, error) {
+	output, err := runGitCommand(worktreeDir, "status", "--porcelain")
+	if err != nil {
+		return false, fmt.Errorf("error, when isWorktreeDirty(). Error: %w", err)
+	}
+	return output != "", nil
+}
+
+// fetchTrunk updates the remote tracking ref explicitly since bare clones are not set up with a fetch refspec
+func fetchTrunk(r repo, trunk string) error {
+	refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", trunk, trunk)
+	_, err := runGitCommand(getBareRepoDir(r), "fetch", "origin", refspec)
+	if err != nil {
+		return fmt.Errorf("error, when fetching %s for %s. Error: %w", trunk, r.Title(), err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_updateRepoFromTrunk(t *testing.T) {
+	r, git := setUpClonedRepo(t)
+	origin := filepath.Join(os.Getenv("HOME"), "origin")
+	write := func(dir string, content string) {
+		t.Helper()
+		err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(content+"\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		git(dir, "add", "file.txt")
+		git(dir, "commit", "-m", content)
+	}
+
+	theEffort := effort{Name: "feature", BranchName: "ABC-1"}
+	worktreeDir := getWorktreeDir(theEffort, r)
+	git(getBareRepoDir(r), "worktree", "add", "-b", theEffort.BranchName, worktreeDir, "main")
+	write(worktreeDir, "effort")
+	before := git(worktreeDir, "rev-parse", "HEAD")
+	write(origin, "trunk")
+
+	for _, strategy := range []string{trunkUpdateStrategyRebase, trunkUpdateStrategyMerge} {
+		t.Run(strategy, func(t *testing.T) {
+			result := updateRepoFromTrunk(theEffort, r, strategy)
+			if result.Outcome != repoOutcomeConflicted || !strings.Contains(result.Detail, "file.txt") {
+				t.Fatalf("got %s: %s, but wanted a conflict in file.txt", result.Outcome, result.Detail)
+			}
+			if after := git(worktreeDir, "rev-parse", "HEAD"); after != before {
+				t.Errorf("got HEAD %s after the conflict, but wanted it left at %s", after, before)
+			}
+			if status := git(worktreeDir, "status", "--porcelain"); status != "" {
+				t.Errorf("got status %q after the conflict, but wanted the %s aborted", status, strategy)
+			}
+		})
+	}
+
+	// once the conflict is gone the update goes through
+	write(origin, "effort")
+	result := updateRepoFromTrunk(theEffort, r, trunkUpdateStrategyRebase)
+	if result.Outcome != repoOutcomeSucceeded {
+		t.Errorf("got %s: %s, but wanted the rebase to succeed", result.Outcome, result.Detail)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// uncommittedWorkMode is how the uncommitted changes of a worktree are kept when it is removed, they are put back
+// when the repo is added to the effort again
+type uncommittedWorkMode string
+
+const (
+	// uncommittedWorkStash stashes the changes and keeps the stash under a ref named after the effort branch
+	uncommittedWorkStash uncommittedWorkMode = "stash"
+	// uncommittedWorkPatch exports the changes as a patch file in the recovery directory
+	uncommittedWorkPatch uncommittedWorkMode = "patch"
+	// uncommittedWorkWip commits the changes to the effort branch, which is then kept instead of deleted
+	uncommittedWorkWip uncommittedWorkMode = "wip"
+)
+
+// wipCommitMessage marks the commits made by uncommittedWorkWip so they can be undone on restore
+const wipCommitMessage = "WIP: uncommitted work saved by git-tool"
+
+// getUncommittedWorkRef is shared by every worktree of the bare repo, so it is named after the effort branch
+func getUncommittedWorkRef(branchName string) string {
+	return "refs/git-tool/uncommitted/" + branchName
+}
+
+func getUncommittedWorkPatchFile(branchName string, r repo) string {
+	return fmt.Sprintf("%suncommitted/%s/%s.patch", recoveryDirectory, branchName, r.Title())
+}
+
+// preserveUncommittedWork saves the uncommitted changes of the worktree the given way and leaves the worktree clean
+func preserveUncommittedWork(worktreeDir string, theEffort effort, r repo, mode uncommittedWorkMode) error {
+	_, err := runGitCommand(worktreeDir, "add", "--all")
+	if err != nil {
+		return fmt.Errorf("error, when staging changes for preserveUncommittedWork(). Error: %w", err)
+	}
+	switch mode {
+	case uncommittedWorkStash:
+		sha, err := runGitCommand(worktreeDir, "stash", "create", "changes to "+theEffort.BranchName+" saved by git-tool")
+		if err != nil {
+			return fmt.Errorf("error, when creating stash for preserveUncommittedWork(). Error: %w", err)
+		}
+		_, err = runGitCommand(worktreeDir, "update-ref", getUncommittedWorkRef(theEffort.BranchName), sha)
+		if err != nil {
+			return fmt.Errorf("error, when saving stash ref for preserveUncommittedWork(). Error: %w", err)
+		}
+	case uncommittedWorkPatch:
+		patch, err := runGitCombinedOutput(worktreeDir, "diff", "--cached", "--binary", "HEAD")
+		if err != nil {
+			return fmt.Errorf("error, when exporting patch for preserveUncommittedWork(). Output: %s. Error: %w", patch, err)
+		}
+		patchFile := getUncommittedWorkPatchFile(theEffort.BranchName, r)
+		err = os.MkdirAll(patchFile[:strings.LastIndex(patchFile, "/")], 0755)
+		if err != nil {
+			return fmt.Errorf("error, when creating patch directory for preserveUncommittedWork(). Error: %w", err)
+		}
+		err = os.WriteFile(patchFile, patch, 0644)
+		if err != nil {
+			return fmt.Errorf("error, when writing patch for preserveUncommittedWork(). Error: %w", err)
+		}
+	case uncommittedWorkWip:
+		_, err = runGitCommand(worktreeDir, "commit", "--no-verify", "-m", wipCommitMessage)
+		if err != nil {
+			return fmt.Errorf("error, when committing for preserveUncommittedWork(). Error: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("error, unknown way to keep uncommitted work: %s", mode)
+	}
+	// the changes are saved, so the worktree is cleaned up for removal
+	_, err = runGitCommand(worktreeDir, "reset", "--hard", "HEAD")
+	if err != nil {
+		return fmt.Errorf("error, when resetting worktree for preserveUncommittedWork(). Error: %w", err)
+	}
+	return nil
+}
+
+// isWipCommit is whether the tip of the branch is a commit made by uncommittedWorkWip
+func isWipCommit(commandDir string, branchName string) (bool, error) {
+	subject, err := runGitCommand(commandDir, "log", "-1", "--format=%s", branchName)
+	if err != nil {
+		return false, fmt.Errorf("error, when reading commit subject for isWipCommit(). Error: %w", err)
+	}
+	return subject == wipCommitMessage, nil
+}
+
+// restoreUncommittedWork puts back whatever preserveUncommittedWork saved for the effort branch of this repo. Saved
+// work that fails to apply is left where it is so nothing is lost.
+func restoreUncommittedWork(worktreeDir string, theEffort effort, r repo) error {
+	commandDir := getBareRepoDir(r)
+	wip, err := isWipCommit(commandDir, theEffort.BranchName)
+	if err != nil {
+		return fmt.Errorf("error, when isWipCommit() for restoreUncommittedWork(). Error: %w", err)
+	}
+	if wip {
+		_, err = runGitCommand(worktreeDir, "reset", "HEAD~1")
+		if err != nil {
+			return fmt.Errorf("error, when undoing WIP commit for restoreUncommittedWork(). Error: %w", err)
+		}
+	}
+
+	ref := getUncommittedWorkRef(theEffort.BranchName)
+	_, err = runGitCommand(commandDir, "rev-parse", "--verify", "--quiet", ref)
+	if err == nil {
+		_, err = runGitCommand(worktreeDir, "stash", "apply", ref)
+		if err != nil {
+			return fmt.Errorf("error, when applying stash %s for restoreUncommittedWork(). Error: %w", ref, err)
+		}
+		_, err = runGitCommand(commandDir, "update-ref", "-d", ref)
+		if err != nil {
+			return fmt.Errorf("error, when removing stash ref for restoreUncommittedWork(). Error: %w", err)
+		}
+	}
+
+	patchFile := getUncommittedWorkPatchFile(theEffort.BranchName, r)
+	_, err = os.Stat(patchFile)
+	if err == nil {
+		_, err = runGitCommand(worktreeDir, "apply", patchFile)
+		if err != nil {
+			return fmt.Errorf("error, when applying patch %s for restoreUncommittedWork(). Error: %w", patchFile, err)
+		}
+		err = os.Remove(patchFile)
+		if err != nil {
+			return fmt.Errorf("error, when removing applied patch for restoreUncommittedWork(). Error: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("error, when checking for patch for restoreUncommittedWork(). Error: %w", err)
+	}
+	return nil
+}
+
+// hasPreservedWork is whether preserveUncommittedWork kept uncommitted work of the branch in git for this repo, as a
+// stash ref or a WIP commit at the tip of the branch. Patches are left out since they are in the recovery directory.
+func hasPreservedWork(r repo, branchName string) (bool, error) {
+	commandDir := getBareRepoDir(r)
+	_, err := runGitCommand(commandDir, "rev-parse", "--verify", "--quiet", getUncommittedWorkRef(branchName))
+	if err == nil {
+		return true, nil
+	}
+	branchExists, err := doesBranchExist(branchName, commandDir)
+	if err != nil {
+		return false, fmt.Errorf("error, when doesBranchExist() for hasPreservedWork(). Error: %w", err)
+	}
+	if !branchExists {
+		return false, nil
+	}
+	wip, err := isWipCommit(commandDir, branchName)
+	if err != nil {
+		return false, fmt.Errorf("error, when isWipCommit() for hasPreservedWork(). Error: %w", err)
+	}
+	return wip, nil
+}
+
+// fetchReposWithPreservedWork checks every cloned repo, not only those of the effort, since removing a repo from an
+// effort is what preserves the work
+func fetchReposWithPreservedWork(theEffort effort) ([]repo, error) {
+	repoItems, err := fetchRepos()
+	if err != nil {
+		return nil, fmt.Errorf("error, when fetchRepos() for fetchReposWithPreservedWork(). Error: %w", err)
+	}
+	var result []repo
+	for _, item := range repoItems {
+		r := item.(repo)
+		exists, err := checkDirectoryExists(getBareRepoDir(r))
+		if err != nil {
+			return nil, fmt.Errorf("error, when checkDirectoryExists() for fetchReposWithPreservedWork(). Error: %w", err)
+		}
+		if !exists {
+			continue
+		}
+		preserved, err := hasPreservedWork(r, theEffort.BranchName)
+		if err != nil {
+			return nil, fmt.Errorf("error, when hasPreservedWork() for fetchReposWithPreservedWork() of repo: %s. Error: %w", r.Title(), err)
+		}
+		if preserved {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
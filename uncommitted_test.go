@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_preserveUncommittedWork(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@test")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@test")
+	root := t.TempDir()
+	previousReposDirectory := reposDirectory
+	previousRecoveryDirectory := recoveryDirectory
+	reposDirectory = root + "/repos/"
+	recoveryDirectory = root + "/recovery/"
+	t.Cleanup(func() {
+		reposDirectory = previousReposDirectory
+		recoveryDirectory = previousRecoveryDirectory
+	})
+	r := repo{Url: "git@example.com:team/app.git"}
+	theEffort := effort{Name: "feature", BranchName: "ABC-1"}
+	// the repo stands in for both the bare repo and the worktree
+	dir := getBareRepoDir(r)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git := func(args ...string) string {
+		t.Helper()
+		output, err := runGitCommand(dir, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+	write := func(file string, content string) {
+		t.Helper()
+		err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-b", theEffort.BranchName)
+	write("tracked.txt", "base")
+	git("add", "tracked.txt")
+	git("commit", "-m", "base")
+
+	for _, mode := range []uncommittedWorkMode{uncommittedWorkStash, uncommittedWorkPatch, uncommittedWorkWip} {
+		t.Run(string(mode), func(t *testing.T) {
+			write("tracked.txt", "changed")
+			write("untracked.txt", "new")
+
+			err := preserveUncommittedWork(dir, theEffort, r, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status := git("status", "--porcelain"); status != "" {
+				t.Fatalf("got status %q after preserving, but wanted a clean worktree", status)
+			}
+
+			err = restoreUncommittedWork(dir, theEffort, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for file, expected := range map[string]string{"tracked.txt": "changed", "untracked.txt": "new"} {
+				content, err := os.ReadFile(filepath.Join(dir, file))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(content) != expected {
+					t.Errorf("got %s with %q after restoring, but wanted %q", file, content, expected)
+				}
+			}
+			if subject := git("log", "-1", "--format=%s"); subject != "base" {
+				t.Errorf("got HEAD %q after restoring, but wanted base", subject)
+			}
+
+			// nothing saved is left to be restored a second time
+			git("add", "--all")
+			git("reset", "--hard", "HEAD")
+			err = restoreUncommittedWork(dir, theEffort, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status := git("status", "--porcelain"); status != "" {
+				t.Errorf("got status %q after restoring again, but wanted nothing restored", status)
+			}
+		})
+	}
+}
+
+func Test_deleteEffort_preservedWork(t *testing.T) {
+	r, git := setUpClonedRepo(t)
+	previousRecoveryDirectory := recoveryDirectory
+	recoveryDirectory = os.Getenv("HOME") + "/git_tool_data/recovery/"
+	t.Cleanup(func() { recoveryDirectory = previousRecoveryDirectory })
+	result, err := database.Exec(`INSERT INTO effort (name, branch_name, description) VALUES ('feature', 'ABC-1', 'feature')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theEffort := effort{Name: "feature", BranchName: "ABC-1"}
+	theEffort.Id, err = result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the repo was taken out of the effort with its changes stashed, so the effort no longer lists it
+	bareDir := getBareRepoDir(r)
+	worktreeDir := filepath.Join(os.Getenv("HOME"), "worktree")
+	git(bareDir, "worktree", "add", "-b", theEffort.BranchName, worktreeDir, "main")
+	err = os.WriteFile(filepath.Join(worktreeDir, "changed.txt"), []byte("changed"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = preserveUncommittedWork(worktreeDir, theEffort, r, uncommittedWorkStash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(bareDir, "worktree", "remove", worktreeDir)
+
+	_, err = deleteEffort(theEffort, false)
+	var preserved *preservedWorkError
+	if !errors.As(err, &preserved) {
+		t.Fatalf("got %v, but wanted a preservedWorkError", err)
+	}
+
+	// once the effort is gone only doctor can point at the stash
+	issues, err := detectOrphanedPreservedWork(r, map[string]bool{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || !strings.Contains(issues[0].Problem, getUncommittedWorkRef(theEffort.BranchName)) {
+		t.Errorf("got issues %v, but wanted the stash ref listed", issues)
+	}
+
+	_, err = deleteEffort(theEffort, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := git(bareDir, "for-each-ref", "refs/git-tool", "refs/heads/"+theEffort.BranchName)
+	if refs != "" {
+		t.Errorf("got refs %q after force deleting, but wanted the branch and stash gone", refs)
+	}
+	bundles, err := filepath.Glob(recoveryDirectory + "*/app.bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != 1 {
+		t.Fatalf("got bundles %v, but wanted one for the repo", bundles)
+	}
+	heads := git(bareDir, "bundle", "list-heads", bundles[0])
+	if !strings.Contains(heads, getUncommittedWorkRef(theEffort.BranchName)) {
+		t.Errorf("got bundle heads %q, but wanted the stash saved", heads)
+	}
+}
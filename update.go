@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -9,15 +15,42 @@ import (
 )
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, isKey := msg.(tea.KeyMsg)
+	if !isKey || m.loading || m.err != nil {
+		return m.update(msg)
+	}
+	// remembering the key press that starts an operation is what lets the error screen retry it
+	before := m
+	before.retry = nil
+	updated, cmd := m.update(msg)
+	after := updated.(model)
+	if after.loading {
+		after.retry = &retryState{model: before, key: keyMsg}
+	}
+	return after, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		// ignore key presses if loading
+		// other key presses are ignored while loading, esc and ctrl+c cancel the running git commands
+		if m.loading && (msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlC) {
+			cancelGitOperation()
+			m.statusMsg = "cancelling, waiting for running commands to stop"
+			return m, cmd
+		}
+		if !m.loading && m.err != nil {
+			return m.updateErrorScreen(msg)
+		}
 		if !m.loading {
 			// reset any errors or validation messages on key press if not loading
 			m.err = nil
 			m.validationMsg = ""
+			m.statusMsg = ""
+			// whatever this key press starts gets its own operation so an earlier cancel doesn't carry over
+			beginOperation(context.Background())
 
 			if msg.Type == tea.KeyCtrlC {
 				return m, tea.Quit
@@ -26,24 +59,55 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch m.activeView {
 			case activeViewDeleteEffort:
 				switch msg.Type {
+				case tea.KeyEsc:
+					m.deleteEffortTextInput.Reset()
+					m.forceDelete = false
+					m.forcePush = false
+					m.activeView = activeViewListEfforts
+					// the delete was started from the effort detail view
+					if m.previousView == activeViewEffortDetail {
+						m.activeView = activeViewEffortDetail
+					}
+					return m, cmd
 				case tea.KeyEnter:
 					if !m.loading {
-						required := m.efforts.SelectedItem().(effort).Name
+						required := m.selectedEffort.Name
+						if m.forceDelete {
+							required = "force delete " + m.selectedEffort.Name
+						} else if m.forcePush {
+							required = "force push " + m.selectedEffort.Name
+						}
 						if m.deleteEffortTextInput.Value() != required {
 							m.validationMsg = fmt.Sprintf("Input must match \"%s\"", required)
+						} else if m.forcePush {
+							theEffort := m.selectedEffort
+							m.deleteEffortTextInput.Reset()
+							m.deleteEffortTextInput.Blur()
+							m.forcePush = false
+							// the report goes back to the efforts rather than the confirmation
+							m.activeView = activeViewListEfforts
+							return m.runEffortAction(func() (string, error) {
+								results, err := pushEffort(theEffort, true, true)
+								if err != nil {
+									return "", fmt.Errorf("error, when pushEffort() for Update(). Error: %w", err)
+								}
+								return formatRepoResults("Force push for "+theEffort.Name, results), nil
+							})
 						} else {
-							theEffort := m.efforts.SelectedItem().(effort)
+							theEffort := m.selectedEffort
+							force := m.forceDelete
 							m.loading = true
 							m.deleteEffortTextInput.Blur()
 							go func() {
 								var md modelData
-								err := deleteEffort(theEffort)
+								results, err := deleteEffort(theEffort, force)
 								if err != nil {
 									md.err = err
 								} else {
 									md.err = nil
 									md.resetControls = true
-									md.activeView = activeViewListEfforts
+									md.activeView = activeViewReport
+									md.report = formatRepoResults(fmt.Sprintf("Deleted effort %s", theEffort.Name), results)
 								}
 								loadingFinished <- md
 							}()
@@ -81,35 +145,115 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 				}
 			case activeViewListEfforts:
-				if m.repos.FilterState() != list.Filtering {
+				if m.efforts.FilterState() != list.Filtering {
 					if key.Matches(msg, addItemKeyBinding) {
 						m.activeView = activeViewAddNewEffort
 						return m, cmd
-					} else if key.Matches(msg, deleteItemKeyBinding) {
+					} else if key.Matches(msg, deleteItemKeyBinding) || key.Matches(msg, forceDeleteEffortBinding) {
+						m.previousView = activeViewListEfforts
 						m.activeView = activeViewDeleteEffort
+						m.forceDelete = key.Matches(msg, forceDeleteEffortBinding)
 						m.selectedEffort = m.efforts.SelectedItem().(effort)
 						m.deleteEffortTextInput.Focus()
 						return m, cmd
 					} else if key.Matches(msg, navigateToReposBinding) {
 						m.activeView = activeViewListRepos
 						return m, cmd
+					} else if key.Matches(msg, navigateToDoctorBinding) {
+						return m.openDoctor()
+					} else if key.Matches(msg, updateFromTrunkBinding) && len(m.efforts.Items()) != 0 {
+						theEffort := m.efforts.SelectedItem().(effort)
+						return m.runEffortAction(func() (string, error) {
+							results, err := updateEffortFromTrunk(theEffort, config.TrunkUpdateStrategy)
+							if err != nil {
+								return "", fmt.Errorf("error, when updateEffortFromTrunk() for Update(). Error: %w", err)
+							}
+							return formatRepoResults("Update from trunk for "+theEffort.Name, results), nil
+						})
+					} else if key.Matches(msg, commitEffortBinding) && len(m.efforts.Items()) != 0 {
+						m.selectedEffort = m.efforts.SelectedItem().(effort)
+						m.activeView = activeViewCommit
+						m.cursor = 0
+						m.commitStageAll = false
+						m.worktreeChanges = nil
+						m.commitMessageTextInput.Reset()
+						m.commitMessageTextInput.Focus()
+						m.loading = true
+						theEffort := m.selectedEffort
+						go func() {
+							md := modelData{activeView: activeViewCommit}
+							md.worktreeChanges, md.err = fetchEffortWorktreeChanges(theEffort)
+							loadingFinished <- md
+						}()
+						return m, m.spinner.Tick
+					} else if key.Matches(msg, forcePushEffortBinding) && len(m.efforts.Items()) != 0 {
+						// force pushing can overwrite what others pushed so it is confirmed like a delete
+						m.previousView = activeViewListEfforts
+						m.activeView = activeViewDeleteEffort
+						m.forcePush = true
+						m.forceDelete = false
+						m.selectedEffort = m.efforts.SelectedItem().(effort)
+						m.deleteEffortTextInput.Focus()
+						return m, cmd
+					} else if key.Matches(msg, pushEffortBinding) && len(m.efforts.Items()) != 0 {
+						theEffort := m.efforts.SelectedItem().(effort)
+						return m.runEffortAction(func() (string, error) {
+							results, err := pushEffort(theEffort, false, true)
+							if err != nil {
+								return "", fmt.Errorf("error, when pushEffort() for Update(). Error: %w", err)
+							}
+							return formatRepoResults("Push for "+theEffort.Name, results), nil
+						})
+					} else if key.Matches(msg, execInEffortBinding) && len(m.efforts.Items()) != 0 {
+						m.selectedEffort = m.efforts.SelectedItem().(effort)
+						m.activeView = activeViewExec
+						m.execCommandTextInput.Focus()
+						return m, cmd
+					} else if key.Matches(msg, searchEffortBinding) && len(m.efforts.Items()) != 0 {
+						m.selectedEffort = m.efforts.SelectedItem().(effort)
+						m.activeView = activeViewSearch
+						m.searchMatches = nil
+						m.cursor = 0
+						m.searchTextInput.Focus()
+						return m, cmd
+					} else if key.Matches(msg, diffEffortBinding) && len(m.efforts.Items()) != 0 {
+						m.selectedEffort = m.efforts.SelectedItem().(effort)
+						m.activeView = activeViewDiff
+						m.diffs = nil
+						m.cursor = 0
+						m.loading = true
+						theEffort := m.selectedEffort
+						go func() {
+							md := modelData{activeView: activeViewDiff}
+							md.diffs, md.err = fetchEffortDiff(theEffort)
+							loadingFinished <- md
+						}()
+						return m, m.spinner.Tick
+					} else if key.Matches(msg, navigateToHistoryBinding) {
+						return m.openHistory()
+					} else if key.Matches(msg, cycleEffortOrderBinding) {
+						m.effortOrder = m.effortOrder.next()
+						m.setEfforts(m.efforts.Items())
+						return m, cmd
+					} else if key.Matches(msg, navigateToAdoptBinding) {
+						m.activeView = activeViewAdopt
+						m.cursor = 0
+						m.adoptionCandidates = nil
+						return m.scanForAdoption(nil)
 					}
 					switch msg.Type {
 					case tea.KeyEnter:
-						if len(m.repos.Items()) == 0 {
-							m.activeView = activeViewAddNewRepo
+						if len(m.efforts.Items()) == 0 {
 							return m, cmd
 						}
 						m.selectedEffort = m.efforts.SelectedItem().(effort)
-						theRepoItems, err := fetchEffortRepoChoices(m.selectedEffort.Id, m.repos)
+						err := markEffortOpened(m.selectedEffort.Id)
 						if err != nil {
-							m.err = fmt.Errorf("error, when fetchSelectedReposForEffort() for Update(). Error: %v", err)
+							m.err = fmt.Errorf("error, when markEffortOpened() for Update(). Error: %w", err)
 							return m, cmd
 						}
-
-						m.repos.SetItems(theRepoItems)
-						m.effortRepoVisibleSelection = updateRepoVisibleSelectionList(m.repos.Items())
-						m.activeView = activeViewEditEffort
+						m.selectedEffort.LastOpenedAt = time.Now()
+						return m.openEffortDetail()
 					}
 				}
 			case activeViewListRepos:
@@ -125,6 +269,296 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					} else if key.Matches(msg, navigateToEffortsBinding) {
 						m.activeView = activeViewListEfforts
 						return m, cmd
+					} else if key.Matches(msg, navigateToDoctorBinding) {
+						return m.openDoctor()
+					} else if key.Matches(msg, navigateToHistoryBinding) {
+						return m.openHistory()
+					}
+				}
+			case activeViewHistory:
+				if m.historyFilterTextInput.Focused() {
+					switch msg.Type {
+					case tea.KeyEsc:
+						m.historyFilterTextInput.Reset()
+						m.historyFilterTextInput.Blur()
+						m.historyFilter.Text = ""
+						return m.loadHistory()
+					case tea.KeyEnter:
+						m.historyFilterTextInput.Blur()
+						m.historyFilter.Text = strings.TrimSpace(m.historyFilterTextInput.Value())
+						return m.loadHistory()
+					}
+				} else {
+					switch msg.String() {
+					case "esc":
+						m.activeView = m.previousView
+					case "k":
+						if m.cursor > 0 {
+							m.cursor--
+						}
+					case "j":
+						if m.cursor < len(m.historyEntries)-1 {
+							m.cursor++
+						}
+					case "enter":
+						m.historyShowOutput = !m.historyShowOutput
+					case "/":
+						m.historyFilterTextInput.Focus()
+					case "t":
+						// cycles through every kind then back to all of them
+						switch m.historyFilter.Kind {
+						case "":
+							m.historyFilter.Kind = operationLogEvent
+						case operationLogEvent:
+							m.historyFilter.Kind = operationLogGit
+						default:
+							m.historyFilter.Kind = ""
+						}
+						return m.loadHistory()
+					case "f":
+						m.historyFilter.FailedOnly = !m.historyFilter.FailedOnly
+						return m.loadHistory()
+					case "x":
+						fileName := "git-tool-history.json"
+						file, err := os.Create(fileName)
+						if err != nil {
+							m.err = fmt.Errorf("error, when creating %s for Update(). Error: %w", fileName, err)
+							return m, cmd
+						}
+						err = exportOperationLog(file, m.historyEntries)
+						file.Close()
+						if err != nil {
+							m.err = fmt.Errorf("error, when exportOperationLog() for Update(). Error: %w", err)
+							return m, cmd
+						}
+						workingDir, _ := os.Getwd()
+						m.statusMsg = fmt.Sprintf("wrote %d entries to %s/%s", len(m.historyEntries), workingDir, fileName)
+					}
+					return m, cmd
+				}
+			case activeViewDoctor:
+				switch msg.String() {
+				case "esc":
+					m.activeView = m.previousView
+				case "k":
+					if m.cursor > 0 {
+						m.cursor--
+					}
+				case "j":
+					if m.cursor < len(m.doctorIssues)-1 {
+						m.cursor++
+					}
+				case "r":
+					return m.runDoctor(nil)
+				case "f":
+					if len(m.doctorIssues) != 0 {
+						return m.runDoctor([]doctorIssue{m.doctorIssues[m.cursor]})
+					}
+				case "F":
+					return m.runDoctor(m.doctorIssues)
+				}
+			case activeViewRecover:
+				switch msg.String() {
+				case "esc":
+					m.activeView = activeViewListEfforts
+					return m, cmd
+				case "k":
+					if m.cursor > 0 {
+						m.cursor--
+					}
+				case "j":
+					if m.cursor < len(m.unfinishedOperations)-1 {
+						m.cursor++
+					}
+				case "r":
+					return m.recoverOperation(resumeOperation)
+				case "b":
+					return m.recoverOperation(rollbackOperation)
+				case "x":
+					if len(m.unfinishedOperations) != 0 {
+						err := abandonOperation(m.unfinishedOperations[m.cursor])
+						if err != nil {
+							m.err = fmt.Errorf("error, when abandonOperation() for Update(). Error: %w", err)
+							return m, cmd
+						}
+						m.unfinishedOperations = append(m.unfinishedOperations[:m.cursor:m.cursor], m.unfinishedOperations[m.cursor+1:]...)
+						m.cursor = max(min(m.cursor, len(m.unfinishedOperations)-1), 0)
+						if len(m.unfinishedOperations) == 0 {
+							m.activeView = activeViewListEfforts
+						}
+					}
+				}
+			case activeViewCommit:
+				switch msg.Type {
+				case tea.KeyEsc:
+					m.activeView = activeViewListEfforts
+					return m, cmd
+				case tea.KeyTab:
+					if m.commitMessageTextInput.Focused() {
+						m.commitMessageTextInput.Blur()
+					} else {
+						m.commitMessageTextInput.Focus()
+					}
+					return m, cmd
+				case tea.KeyEnter:
+					theEffort := m.selectedEffort
+					changes := m.worktreeChanges
+					message := m.commitMessageTextInput.Value()
+					stageAll := m.commitStageAll
+					m.activeView = activeViewListEfforts
+					return m.runEffortAction(func() (string, error) {
+						results, validationMsg := commitEffort(theEffort, changes, message, stageAll)
+						if validationMsg != "" {
+							return validationMsg, nil
+						}
+						return formatRepoResults("Commit for "+theEffort.Name, results), nil
+					})
+				}
+				if !m.commitMessageTextInput.Focused() {
+					switch msg.String() {
+					case "k":
+						if m.cursor > 0 {
+							m.cursor--
+						}
+					case "j":
+						if m.cursor < len(m.worktreeChanges)-1 {
+							m.cursor++
+						}
+					case " ":
+						if len(m.worktreeChanges) != 0 {
+							m.worktreeChanges[m.cursor].Selected = !m.worktreeChanges[m.cursor].Selected
+						}
+					case "a":
+						m.commitStageAll = !m.commitStageAll
+					}
+					return m, cmd
+				}
+			case activeViewExec:
+				switch msg.Type {
+				case tea.KeyEsc:
+					m.activeView = activeViewListEfforts
+					return m, cmd
+				case tea.KeyEnter:
+					command := strings.TrimSpace(m.execCommandTextInput.Value())
+					if command == "" {
+						m.validationMsg = "must provide a command"
+						return m, cmd
+					}
+					theEffort := m.selectedEffort
+					m.activeView = activeViewListEfforts
+					return m.runEffortAction(func() (string, error) {
+						results, err := execInEffort(theEffort, []string{"sh", "-c", command}, config.ExecConcurrency, nil)
+						if err != nil {
+							return "", fmt.Errorf("error, when execInEffort() for Update(). Error: %w", err)
+						}
+						return formatExecResults(command, results, 10), nil
+					})
+				}
+			case activeViewSearch:
+				if m.searchTextInput.Focused() {
+					switch msg.Type {
+					case tea.KeyEsc:
+						m.activeView = activeViewListEfforts
+						return m, cmd
+					case tea.KeyEnter:
+						theEffort := m.selectedEffort
+						pattern := m.searchTextInput.Value()
+						m.searchTextInput.Blur()
+						m.loading = true
+						go func() {
+							md := modelData{activeView: activeViewSearch}
+							md.searchMatches, md.err = searchEffort(theEffort, pattern, false)
+							loadingFinished <- md
+						}()
+						return m, m.spinner.Tick
+					}
+				} else {
+					switch msg.String() {
+					case "esc":
+						m.activeView = activeViewListEfforts
+					case "/":
+						m.searchTextInput.Focus()
+					case "k":
+						if m.cursor > 0 {
+							m.cursor--
+						}
+					case "j":
+						if m.cursor < len(m.searchMatches)-1 {
+							m.cursor++
+						}
+					case "enter":
+						if len(m.searchMatches) != 0 {
+							return m, tea.ExecProcess(editorCommand(m.searchMatches[m.cursor]), func(err error) tea.Msg {
+								if err != nil {
+									return errMsg(fmt.Errorf("error, when opening editor. Error: %w", err))
+								}
+								return nil
+							})
+						}
+					}
+					return m, cmd
+				}
+			case activeViewDiff:
+				nodes := getDiffNodes(m.diffs)
+				switch msg.String() {
+				case "esc":
+					m.activeView = activeViewListEfforts
+				case "k":
+					if m.cursor > 0 {
+						m.cursor--
+					}
+				case "j":
+					if m.cursor < len(nodes)-1 {
+						m.cursor++
+					}
+				case "enter", " ":
+					if len(nodes) != 0 {
+						node := nodes[m.cursor]
+						if node.fileIndex == -1 {
+							m.diffs[node.repoIndex].Expanded = !m.diffs[node.repoIndex].Expanded
+						} else {
+							m.diffs[node.repoIndex].Files[node.fileIndex].Expanded = !m.diffs[node.repoIndex].Files[node.fileIndex].Expanded
+						}
+					}
+				case "e", "m":
+					content := formatDiffPatch(m.diffs)
+					extension := "patch"
+					if msg.String() == "m" {
+						content = formatDiffMarkdown(m.selectedEffort, m.diffs)
+						extension = "md"
+					}
+					fileName := fmt.Sprintf("%s.%s", m.selectedEffort.Name, extension)
+					err := os.WriteFile(fileName, []byte(content), 0644)
+					if err != nil {
+						m.err = fmt.Errorf("error, when writing %s for Update(). Error: %w", fileName, err)
+						return m, cmd
+					}
+					workingDir, _ := os.Getwd()
+					m.statusMsg = fmt.Sprintf("wrote %s/%s", workingDir, fileName)
+				}
+				return m, cmd
+			case activeViewReport:
+				if msg.Type == tea.KeyEsc || msg.Type == tea.KeyEnter {
+					// returning right away so the list being returned to doesn't also handle esc and quit
+					m.activeView = m.previousView
+					return m, cmd
+				}
+			case activeViewAdopt:
+				switch msg.String() {
+				case "esc":
+					m.activeView = activeViewListEfforts
+				case "k":
+					if m.cursor > 0 {
+						m.cursor--
+					}
+				case "j":
+					if m.cursor < len(m.adoptionCandidates)-1 {
+						m.cursor++
+					}
+				case "enter":
+					if len(m.adoptionCandidates) != 0 {
+						candidate := m.adoptionCandidates[m.cursor]
+						return m.scanForAdoption(&candidate)
 					}
 				}
 			case activeViewAddNewRepo:
@@ -147,7 +581,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 								md.resetControls = true
 								repos, err := fetchRepos()
 								if err != nil {
-									md.err = fmt.Errorf("error, when fetchRepos() for Update(). Error: %v", err)
+									md.err = fmt.Errorf("error, when fetchRepos() for Update(). Error: %w", err)
 								} else {
 									m.repos.SetItems(repos)
 									md.repos = m.repos
@@ -181,10 +615,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 					efforts, err := fetchEfforts()
 					if err != nil {
-						m.err = fmt.Errorf("error, when fetchEfforts() for Update() after adding effort. Error: %v", err)
+						m.err = fmt.Errorf("error, when fetchEfforts() for Update() after adding effort. Error: %w", err)
 						return m, cmd
 					}
-					m.efforts.SetItems(efforts)
+					m.setEfforts(efforts)
 					m.activeView = activeViewListEfforts
 				case tea.KeyTab:
 					if m.addNewEffortNameTextInput.Focused() {
@@ -195,7 +629,79 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.addNewEffortBranchNameTextInput.Blur()
 					}
 				}
+			case activeViewEffortDetail:
+				switch msg.String() {
+				case "k":
+					if m.cursor > 0 {
+						m.cursor--
+					}
+				case "j":
+					if m.cursor < len(m.effortDetails)-1 {
+						m.cursor++
+					}
+				case "e":
+					return m.openEditEffort()
+				case "o":
+					if m.cursor < len(m.effortDetails) && m.effortDetails[m.cursor].Branch != "" {
+						return m, tea.ExecProcess(shellCommand(m.effortDetails[m.cursor].WorktreeDir), func(err error) tea.Msg {
+							if err != nil {
+								return errMsg(fmt.Errorf("error, when opening a shell in the worktree. Error: %w", err))
+							}
+							return nil
+						})
+					}
+				case "O":
+					effortDir, err := getEffortDir(m.selectedEffort.Name)
+					if err != nil {
+						m.err = fmt.Errorf("error, when getEffortDir() for Update(). Error: %w", err)
+						return m, cmd
+					}
+					return m, tea.ExecProcess(shellCommand(effortDir), func(err error) tea.Msg {
+						if err != nil {
+							return errMsg(fmt.Errorf("error, when opening a shell in the effort directory. Error: %w", err))
+						}
+						return nil
+					})
+				case "d", "D":
+					m.previousView = activeViewEffortDetail
+					m.activeView = activeViewDeleteEffort
+					m.forceDelete = msg.String() == "D"
+					m.deleteEffortTextInput.Focus()
+				case "esc":
+					m.activeView = activeViewListEfforts
+					// opening the effort moved it up when sorting by recency
+					err := m.reloadEfforts()
+					if err != nil {
+						m.err = fmt.Errorf("error, when reloadEfforts() for Update() after showing effort. Error: %w", err)
+					}
+				}
+				return m, cmd
 			case activeViewEditEffort:
+				if m.pickingOtherEffort {
+					others := m.otherEffortsWithRepos()
+					switch msg.String() {
+					case "k":
+						if m.otherEffortCursor > 0 {
+							m.otherEffortCursor--
+						}
+					case "j":
+						if m.otherEffortCursor < len(others)-1 {
+							m.otherEffortCursor++
+						}
+					case "enter":
+						if len(others) != 0 {
+							m.repoSelectionUndo = repoSelections(m.repos.Items())
+							m.repos.SetItems(selectReposOfEffort(m.repos.Items(), others[m.otherEffortCursor]))
+							m.effortRepoVisibleSelection = updateRepoVisibleSelectionList(
+								updateRepos(m.repos.Items(), m.listFilterTextInput.Value(), nil),
+							)
+						}
+						m.pickingOtherEffort = false
+					case "esc":
+						m.pickingOtherEffort = false
+					}
+					return m, cmd
+				}
 				if m.listFilterLive {
 					switch msg.Type {
 					case tea.KeyEsc:
@@ -220,7 +726,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				} else {
 					switch msg.Type {
 					case tea.KeyEsc:
-						m.activeView = activeViewListEfforts
+						m.activeView = activeViewEffortDetail
+						m.cursor = 0
+						return m, cmd
 					case tea.KeyEnter:
 						if !m.loading {
 							m.loading = true
@@ -231,27 +739,70 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 									md.err = err
 									md.validationMsg = validationMsg
 								} else {
-									md.err = nil
+									// the detail view shows what the selection did
+									md.effortDetails, md.err = fetchEffortDetail(m.selectedEffort)
 									md.validationMsg = ""
 									md.resetControls = true
-									md.activeView = activeViewListEfforts
+									md.activeView = activeViewEffortDetail
 								}
 								loadingFinished <- md
 							}()
 							return m, m.spinner.Tick
 						}
 					case tea.KeySpace:
+						// nothing matches the filter
+						if len(m.effortRepoVisibleSelection) == 0 {
+							return m, cmd
+						}
+						m.repoSelectionUndo = repoSelections(m.repos.Items())
 						m.effortRepoVisibleSelection[m.cursor].Selected = !m.effortRepoVisibleSelection[m.cursor].Selected
-						theRepos := updateRepos(
-							m.repos.Items(),
-							m.listFilterTextInput.Value(),
-							m.effortRepoVisibleSelection,
-						)
-						m.repos.SetItems(theRepos)
-						m.effortRepoVisibleSelection = updateRepoVisibleSelectionList(m.repos.Items())
-
+						m.syncRepoSelection()
+					}
+					// any other key ends a range selection
+					if msg.String() != "J" && msg.String() != "K" {
+						m.selectingRange = false
 					}
 					switch msg.String() {
+					case "J", "K":
+						if len(m.effortRepoVisibleSelection) == 0 {
+							return m, cmd
+						}
+						if !m.selectingRange {
+							m.selectingRange = true
+							m.rangeAnchor = m.cursor
+							m.repoSelectionUndo = repoSelections(m.repos.Items())
+						}
+						if msg.String() == "J" {
+							m.cursor = min(m.cursor+1, len(m.effortRepoVisibleSelection)-1)
+						} else {
+							m.cursor = max(m.cursor-1, 0)
+						}
+						selectRepoRange(m.effortRepoVisibleSelection, m.repoSelectionUndo, m.rangeAnchor, m.cursor)
+						m.syncRepoSelection()
+					case "a", "n", "i":
+						m.repoSelectionUndo = repoSelections(m.repos.Items())
+						switch msg.String() {
+						case "a":
+							setReposSelected(m.effortRepoVisibleSelection, true)
+						case "n":
+							setReposSelected(m.effortRepoVisibleSelection, false)
+						case "i":
+							invertRepoSelection(m.effortRepoVisibleSelection)
+						}
+						m.syncRepoSelection()
+					case "e":
+						m.pickingOtherEffort = true
+						m.otherEffortCursor = 0
+					case "u":
+						if m.repoSelectionUndo != nil {
+							// undoing again puts the change back
+							redo := repoSelections(m.repos.Items())
+							m.repos.SetItems(applyRepoSelections(m.repos.Items(), m.repoSelectionUndo))
+							m.repoSelectionUndo = redo
+							m.effortRepoVisibleSelection = updateRepoVisibleSelectionList(
+								updateRepos(m.repos.Items(), m.listFilterTextInput.Value(), nil),
+							)
+						}
 					case "k":
 						if m.cursor > 0 {
 							m.cursor--
@@ -260,6 +811,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						if m.cursor < len(m.effortRepoVisibleSelection)-1 {
 							m.cursor++
 						}
+					case "pgup", "ctrl+u":
+						m.cursor = max(m.cursor-m.repoPickerHeight(), 0)
+					case "pgdown", "ctrl+d":
+						m.cursor = max(min(m.cursor+m.repoPickerHeight(), len(m.effortRepoVisibleSelection)-1), 0)
+					case "home", "g":
+						m.cursor = 0
+					case "end", "G":
+						m.cursor = max(len(m.effortRepoVisibleSelection)-1, 0)
 					case "/":
 						m.listFilterLive = true
 						m.listFilterTextInput.Reset()
@@ -281,7 +840,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case md := <-loadingFinished:
 			m.resetSpinner()
 			m.loading = false
+			m.statusMsg = ""
 			m.err = md.err
+			// the retry belongs to the error of the operation, once it succeeded there is nothing left to retry
+			if md.err == nil {
+				m.retry = nil
+			}
 			m.validationMsg = md.validationMsg
 			switch m.activeView {
 			case activeViewAddNewRepo:
@@ -289,32 +853,126 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if md.resetControls {
 					m.addNewRepoTextInput.Reset()
 				}
-				m.repos = md.repos
-				m.activeView = md.activeView
+				// the repos and view are only sent back when the repo was added
+				if md.activeView != "" {
+					m.repos = md.repos
+					m.activeView = md.activeView
+				}
 			case activeViewEditEffort:
 				if md.resetControls {
 					m.effortRepoVisibleSelection = resetRepoSelection(m.effortRepoVisibleSelection)
 				}
-				m.activeView = md.activeView
+				if md.activeView != "" {
+					m.activeView = md.activeView
+					m.effortDetails = md.effortDetails
+					m.cursor = 0
+				}
+				err := m.reloadEfforts()
+				if err != nil {
+					m.err = fmt.Errorf("error, when reloadEfforts() for Update() after applying repo selection. Error: %w", err)
+					return m, cmd
+				}
+				m.refreshSelectedEffort()
+			case activeViewEffortDetail:
+				m.effortDetails = md.effortDetails
 			case activeViewDeleteEffort:
 				if md.resetControls {
 					m.deleteEffortTextInput.Reset()
+					m.forceDelete = false
+				}
+				if md.err != nil {
+					// staying put so the error screen is dismissed back to the confirmation
+					m.deleteEffortTextInput.Focus()
+				} else {
+					m.report = md.report
+					m.previousView = activeViewListEfforts
+					m.activeView = md.activeView
+				}
+				efforts, err := fetchEfforts()
+				if err != nil {
+					m.err = fmt.Errorf("error, when fetchEfforts() for Update() after deleting effort. Error: %w", err)
+					return m, cmd
+				}
+				m.setEfforts(efforts)
+			case activeViewDoctor:
+				m.doctorIssues = md.doctorIssues
+				if m.cursor >= len(m.doctorIssues) {
+					m.cursor = max(len(m.doctorIssues)-1, 0)
+				}
+				// fixes can add or remove repos and efforts
+				repos, err := fetchRepos()
+				if err != nil {
+					m.err = fmt.Errorf("error, when fetchRepos() for Update() after running doctor. Error: %w", err)
+					return m, cmd
+				}
+				m.repos.SetItems(repos)
+				efforts, err := fetchEfforts()
+				if err != nil {
+					m.err = fmt.Errorf("error, when fetchEfforts() for Update() after running doctor. Error: %w", err)
+					return m, cmd
+				}
+				m.setEfforts(efforts)
+			case activeViewRecover:
+				m.unfinishedOperations = md.unfinishedOperations
+				m.cursor = max(min(m.cursor, len(m.unfinishedOperations)-1), 0)
+				// resuming and rolling back can add or remove efforts and worktrees
+				repos, err := fetchRepos()
+				if err != nil {
+					m.err = fmt.Errorf("error, when fetchRepos() for Update() after recovering an operation. Error: %w", err)
+					return m, cmd
+				}
+				m.repos.SetItems(repos)
+				efforts, err := fetchEfforts()
+				if err != nil {
+					m.err = fmt.Errorf("error, when fetchEfforts() for Update() after recovering an operation. Error: %w", err)
+					return m, cmd
+				}
+				m.setEfforts(efforts)
+				if md.err == nil {
+					m.report = md.report
+					m.previousView = activeViewRecover
+					if len(m.unfinishedOperations) == 0 {
+						m.previousView = activeViewListEfforts
+					}
+					m.activeView = activeViewReport
+				}
+			case activeViewReport:
+				m.report = md.report
+			case activeViewCommit:
+				m.worktreeChanges = md.worktreeChanges
+			case activeViewSearch:
+				m.searchMatches = md.searchMatches
+				m.cursor = 0
+			case activeViewHistory:
+				m.historyEntries = md.historyEntries
+				m.historyShowOutput = false
+				m.cursor = 0
+			case activeViewDiff:
+				m.diffs = md.diffs
+			case activeViewAdopt:
+				m.adoptionCandidates = md.adoptionCandidates
+				if m.cursor >= len(m.adoptionCandidates) {
+					m.cursor = max(len(m.adoptionCandidates)-1, 0)
 				}
-				m.activeView = md.activeView
 				efforts, err := fetchEfforts()
 				if err != nil {
-					m.err = fmt.Errorf("error, when fetchEfforts() for Update() after deleting effort. Error: %v", err)
+					m.err = fmt.Errorf("error, when fetchEfforts() for Update() after adopting. Error: %w", err)
 					return m, cmd
 				}
-				m.efforts.SetItems(efforts)
+				m.setEfforts(efforts)
 			case activeViewDeleteRepo:
 				if md.resetControls {
 					m.deleteRepoTextInput.Reset()
 				}
-				m.activeView = md.activeView
+				if md.err != nil {
+					// staying put so the error screen is dismissed back to the confirmation
+					m.deleteRepoTextInput.Focus()
+				} else {
+					m.activeView = md.activeView
+				}
 				repos, err := fetchRepos()
 				if err != nil {
-					m.err = fmt.Errorf("error, when fetchRepos() for Update() after deleting repo. Error: %v", err)
+					m.err = fmt.Errorf("error, when fetchRepos() for Update() after deleting repo. Error: %w", err)
 					return m, cmd
 				}
 				m.repos.SetItems(repos)
@@ -324,10 +982,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 	case tea.WindowSizeMsg:
+		m.windowHeight = msg.Height
 		h, v := docStyle.GetFrameSize()
 		m.repos.SetSize(msg.Width-h, msg.Height-v)
 		m.efforts.SetSize(msg.Width-h, msg.Height-v)
 	case errMsg:
+		// not the error of an operation, so retrying would replay whatever operation ran last
+		m.retry = nil
 		m.err = msg
 		return m, nil
 	}
@@ -347,6 +1008,284 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.deleteEffortTextInput, cmd = m.deleteEffortTextInput.Update(msg)
 	case activeViewDeleteRepo:
 		m.deleteRepoTextInput, cmd = m.deleteRepoTextInput.Update(msg)
+	case activeViewCommit:
+		m.commitMessageTextInput, cmd = m.commitMessageTextInput.Update(msg)
+	case activeViewExec:
+		m.execCommandTextInput, cmd = m.execCommandTextInput.Update(msg)
+	case activeViewSearch:
+		m.searchTextInput, cmd = m.searchTextInput.Update(msg)
+	case activeViewHistory:
+		m.historyFilterTextInput, cmd = m.historyFilterTextInput.Update(msg)
 	}
 	return m, cmd
 }
+
+// setEfforts sorts the efforts in the chosen order, keeping the cursor on the effort it was on
+func (m *model) setEfforts(efforts []list.Item) {
+	selected, hasSelection := m.efforts.SelectedItem().(effort)
+	m.efforts.SetItems(sortEfforts(efforts, m.effortOrder, time.Now()))
+	m.efforts.Title = effortsListTitle(m.effortOrder)
+	if !hasSelection {
+		return
+	}
+	for i, item := range m.efforts.VisibleItems() {
+		if item.(effort).Id == selected.Id {
+			m.efforts.Select(i)
+			return
+		}
+	}
+}
+
+// reloadEfforts picks up timestamps and repo counts that changed since the efforts were fetched
+func (m *model) reloadEfforts() error {
+	efforts, err := fetchEfforts()
+	if err != nil {
+		return fmt.Errorf("error, when fetchEfforts() for reloadEfforts(). Error: %w", err)
+	}
+	m.setEfforts(efforts)
+	return nil
+}
+
+func (m model) openEffortDetail() (tea.Model, tea.Cmd) {
+	m.activeView = activeViewEffortDetail
+	m.cursor = 0
+	m.effortDetails = nil
+	m.loading = true
+	theEffort := m.selectedEffort
+	go func() {
+		md := modelData{activeView: activeViewEffortDetail}
+		md.effortDetails, md.err = fetchEffortDetail(theEffort)
+		loadingFinished <- md
+	}()
+	return m, m.spinner.Tick
+}
+
+// openEditEffort shows the repo checklist of the selected effort with its repos selected
+func (m model) openEditEffort() (tea.Model, tea.Cmd) {
+	if len(m.repos.Items()) == 0 {
+		m.activeView = activeViewAddNewRepo
+		return m, nil
+	}
+	theRepoItems, err := fetchEffortRepoChoices(m.selectedEffort.Id, m.repos)
+	if err != nil {
+		m.err = fmt.Errorf("error, when fetchEffortRepoChoices() for openEditEffort(). Error: %w", err)
+		return m, nil
+	}
+	m.repos.SetItems(theRepoItems)
+	m.effortRepoVisibleSelection = updateRepoVisibleSelectionList(m.repos.Items())
+	m.repoSelectionUndo = nil
+	m.selectingRange = false
+	m.pickingOtherEffort = false
+	m.cursor = 0
+	m.activeView = activeViewEditEffort
+	return m, nil
+}
+
+// refreshSelectedEffort picks up the repos and timestamps of the selected effort after the efforts are reloaded
+func (m *model) refreshSelectedEffort() {
+	for _, item := range m.efforts.Items() {
+		if item.(effort).Id == m.selectedEffort.Id {
+			m.selectedEffort = item.(effort)
+			return
+		}
+	}
+}
+
+// syncRepoSelection carries the selections made in the visible repos of the edit effort view over to every repo
+func (m *model) syncRepoSelection() {
+	theRepos := updateRepos(
+		m.repos.Items(),
+		m.listFilterTextInput.Value(),
+		m.effortRepoVisibleSelection,
+	)
+	m.repos.SetItems(theRepos)
+	m.effortRepoVisibleSelection = updateRepoVisibleSelectionList(m.repos.Items())
+}
+
+// otherEffortsWithRepos are the efforts whose repos can be copied into the selection of the effort being edited
+func (m model) otherEffortsWithRepos() []effort {
+	var result []effort
+	for _, item := range m.efforts.Items() {
+		e := item.(effort)
+		if e.Id != m.selectedEffort.Id && len(e.Repos) != 0 {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (m model) openDoctor() (tea.Model, tea.Cmd) {
+	m.previousView = m.activeView
+	m.activeView = activeViewDoctor
+	m.cursor = 0
+	m.doctorIssues = nil
+	return m.runDoctor(nil)
+}
+
+func (m model) openHistory() (tea.Model, tea.Cmd) {
+	m.previousView = m.activeView
+	m.activeView = activeViewHistory
+	m.historyEntries = nil
+	m.historyFilter = operationLogFilter{Limit: 1000}
+	m.historyFilterTextInput.Reset()
+	return m.loadHistory()
+}
+
+// loadHistory queries the history again with the current filters
+func (m model) loadHistory() (tea.Model, tea.Cmd) {
+	m.loading = true
+	filter := m.historyFilter
+	go func() {
+		md := modelData{activeView: activeViewHistory}
+		md.historyEntries, md.err = fetchOperationLog(filter)
+		loadingFinished <- md
+	}()
+	return m, m.spinner.Tick
+}
+
+// runDoctor applies the given fixes then scans again so the screen always shows what is left
+func (m model) runDoctor(toFix []doctorIssue) (tea.Model, tea.Cmd) {
+	m.loading = true
+	go func() {
+		md := modelData{activeView: activeViewDoctor}
+		var failures []string
+		if len(toFix) != 0 {
+			_, err := backupDatabase("pre_doctor_fix")
+			if err != nil {
+				md.err = fmt.Errorf("error, when backupDatabase() for runDoctor(), nothing was fixed. Error: %w", err)
+				toFix = nil
+			}
+		}
+		for _, issue := range toFix {
+			err := fixDoctorIssue(issue)
+			if err != nil {
+				failures = append(failures, err.Error())
+			}
+		}
+		issues, err := detectDoctorIssues()
+		if err != nil {
+			md.err = fmt.Errorf("error, when detectDoctorIssues() for runDoctor(). Error: %w", err)
+		} else if len(failures) != 0 {
+			md.validationMsg = strings.Join(failures, "\n")
+		}
+		md.doctorIssues = issues
+		loadingFinished <- md
+	}()
+	return m, m.spinner.Tick
+}
+
+// recoverOperation resumes or rolls back the selected unfinished operation then looks for what is left to recover
+func (m model) recoverOperation(recover func(j *operationJournal) ([]repoResult, error)) (tea.Model, tea.Cmd) {
+	if len(m.unfinishedOperations) == 0 {
+		return m, nil
+	}
+	journal := m.unfinishedOperations[m.cursor]
+	m.loading = true
+	go func() {
+		md := modelData{activeView: activeViewRecover}
+		results, err := recover(journal)
+		if err != nil {
+			md.err = fmt.Errorf("error, when recovering operation %d. Error: %w", journal.Id, err)
+		}
+		md.report = formatRepoResults(journal.Title(), results)
+		md.unfinishedOperations, err = fetchUnfinishedOperations()
+		if err != nil && md.err == nil {
+			md.err = fmt.Errorf("error, when fetchUnfinishedOperations() for recoverOperation(). Error: %w", err)
+		}
+		loadingFinished <- md
+	}()
+	return m, m.spinner.Tick
+}
+
+// scanForAdoption adopts the candidate if one is given then scans again for what is left to adopt
+func (m model) scanForAdoption(toAdopt *adoptionCandidate) (tea.Model, tea.Cmd) {
+	m.loading = true
+	go func() {
+		md := modelData{activeView: activeViewAdopt}
+		if toAdopt != nil {
+			err := adoptCandidate(*toAdopt, "")
+			if err != nil {
+				md.err = fmt.Errorf("error, when adoptCandidate() for scanForAdoption(). Error: %w", err)
+			}
+		}
+		candidates, err := scanAdoptionCandidates()
+		if err != nil && md.err == nil {
+			md.err = fmt.Errorf("error, when scanAdoptionCandidates() for scanForAdoption(). Error: %w", err)
+		}
+		md.adoptionCandidates = candidates
+		loadingFinished <- md
+	}()
+	return m, m.spinner.Tick
+}
+
+// runEffortAction runs an action against the repos of an effort in the background then shows its report
+func (m model) runEffortAction(action func() (string, error)) (tea.Model, tea.Cmd) {
+	m.previousView = m.activeView
+	m.activeView = activeViewReport
+	m.report = ""
+	m.loading = true
+	go func() {
+		var md modelData
+		md.report, md.err = action()
+		loadingFinished <- md
+	}()
+	return m, m.spinner.Tick
+}
+
+// updateErrorScreen handles key presses while an error is showing, nothing else is reachable until it is dismissed
+func (m model) updateErrorScreen(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+	err := m.err
+	switch msg.String() {
+	case "esc", "enter":
+		m.err = nil
+		m.validationMsg = ""
+		m.retry = nil
+	case "r":
+		if m.retry != nil {
+			return m.retry.model.Update(m.retry.key)
+		}
+	case "o":
+		if _, worktreeDir, ok := errorWorktree(err); ok {
+			m.err = nil
+			return m, tea.ExecProcess(shellCommand(worktreeDir), func(err error) tea.Msg {
+				if err != nil {
+					return errMsg(fmt.Errorf("error, when opening a shell in the worktree. Error: %w", err))
+				}
+				return nil
+			})
+		}
+	case "f":
+		if theEffort, ok := errorForceDeletableEffort(err); ok {
+			m.err = nil
+			m.validationMsg = ""
+			m.selectedEffort = theEffort
+			m.forceDelete = true
+			m.previousView = activeViewListEfforts
+			m.deleteEffortTextInput.Reset()
+			m.deleteEffortTextInput.Focus()
+			m.activeView = activeViewDeleteEffort
+		}
+	case "s", "p", "w":
+		var dirty *dirtyWorktreeError
+		if m.retry != nil && errors.As(err, &dirty) {
+			// only local git commands, quick enough to not need the spinner
+			preserveErr := preserveUncommittedWork(dirty.WorktreeDir, dirty.theEffort, dirty.theRepo, errorUncommittedWorkModes[msg.String()])
+			if preserveErr != nil {
+				m.err = fmt.Errorf("error, when preserveUncommittedWork() for the error screen. Error: %w", preserveErr)
+				return m, nil
+			}
+			return m.retry.model.Update(m.retry.key)
+		}
+	case "e":
+		var inUse *repoInUseError
+		if errors.As(err, &inUse) {
+			m.err = nil
+			m.deleteRepoTextInput.Reset()
+			m.activeView = activeViewListEfforts
+		}
+	}
+	return m, nil
+}
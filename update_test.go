@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func Test_Update_retryIsClearedOnceTheOperationSucceeds(t *testing.T) {
+	// the snapshot is the delete confirmation the operation was started from
+	before := model{activeView: activeViewDeleteEffort}
+	m := model{
+		activeView: activeViewReport,
+		loading:    true,
+		retry:      &retryState{model: before, key: tea.KeyMsg{Type: tea.KeyEnter}},
+	}
+	loadingFinished <- modelData{report: "deleted"}
+	updated, _ := m.Update(spinner.TickMsg{})
+	m = updated.(model)
+	if m.retry != nil {
+		t.Fatalf("got a retry of %s after the operation succeeded, but wanted none", m.retry.model.activeView)
+	}
+
+	// an error raised later without an operation must not replay the delete
+	updated, _ = m.Update(errMsg(errors.New("opening a shell failed")))
+	m = updated.(model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = updated.(model)
+	if m.activeView != activeViewReport || m.err == nil {
+		t.Errorf("got view %s with error %v, but wanted the error still showing over the report", m.activeView, m.err)
+	}
+}
+
+func Test_Update_retryIsKeptForTheErrorOfTheOperation(t *testing.T) {
+	before := model{activeView: activeViewDeleteEffort}
+	m := model{
+		activeView: activeViewReport,
+		loading:    true,
+		retry:      &retryState{model: before, key: tea.KeyMsg{Type: tea.KeyEnter}},
+	}
+	loadingFinished <- modelData{err: errors.New("push failed")}
+	updated, _ := m.Update(spinner.TickMsg{})
+	m = updated.(model)
+	if m.retry == nil {
+		t.Fatal("got no retry, but wanted the failed operation to be retryable")
+	}
+
+	// dismissing the error drops the retry along with it
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(model)
+	if m.retry != nil {
+		t.Errorf("got a retry after dismissing the error, but wanted none")
+	}
+}
+
+func Test_Update_forcePushIsConfirmed(t *testing.T) {
+	theEffort := effort{Id: 1, Name: "billing", BranchName: "ABC-1"}
+	m := model{
+		activeView:            activeViewListEfforts,
+		efforts:               list.New([]list.Item{theEffort}, newEffortDelegate(), 80, 20),
+		deleteEffortTextInput: textinput.New(),
+	}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+	m = updated.(model)
+	if m.loading || m.activeView != activeViewDeleteEffort || !m.forcePush {
+		t.Fatalf("got view %s loading %v, but wanted the force push confirmation", m.activeView, m.loading)
+	}
+
+	m.deleteEffortTextInput.SetValue("billing")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.loading || m.validationMsg != `Input must match "force push billing"` {
+		t.Errorf("got loading %v with %q, but wanted the push held back until it is confirmed", m.loading, m.validationMsg)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(model)
+	if m.forcePush || m.activeView != activeViewListEfforts {
+		t.Errorf("got view %s with force push %v after esc, but wanted the efforts list", m.activeView, m.forcePush)
+	}
+}
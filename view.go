@@ -7,17 +7,41 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
 var highlightStyle = lipgloss.NewStyle().Bold(true).Background(lipgloss.Color("226")).Foreground(lipgloss.Color("#000000"))
 
 func (m model) View() string {
 	if m.err != nil {
-		return getErrorStyle(m.err.Error())
+		var actions []string
+		for _, action := range errorActions(m.err, m.retry != nil) {
+			actions = append(actions, fmt.Sprintf("%s %s", action.Key, action.Description))
+		}
+		return docStyle.Render(fmt.Sprintf(
+			"Something went wrong%s\n\n%s",
+			getErrorStyle(m.err.Error()),
+			helpStyle.Render(strings.Join(actions, "\n")),
+		))
 	}
 
 	var display string
 	switch m.activeView {
 	case activeViewDeleteEffort:
 		titlePrefix := fmt.Sprintf("Delete effort \"%s\"", m.selectedEffort.Name)
+		if m.forceDelete {
+			titlePrefix = fmt.Sprintf(
+				"Force delete effort \"%s\"\nunmerged and uncommitted work is saved to %s first\ntype \"force delete %s\" to confirm",
+				m.selectedEffort.Name,
+				recoveryDirectory,
+				m.selectedEffort.Name,
+			)
+		} else if m.forcePush {
+			titlePrefix = fmt.Sprintf(
+				"Force push effort \"%s\"\nevery repo is pushed with --force-with-lease\ntype \"force push %s\" to confirm",
+				m.selectedEffort.Name,
+				m.selectedEffort.Name,
+			)
+		}
 		var title string
 		if m.loading {
 			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
@@ -29,7 +53,7 @@ func (m model) View() string {
 			title,
 			m.deleteEffortTextInput.View(),
 		)
-    case activeViewDeleteRepo:
+	case activeViewDeleteRepo:
 		titlePrefix := fmt.Sprintf("Delete repo \"%s\"", m.selectedRepo.Title())
 		var title string
 		if m.loading {
@@ -61,6 +85,43 @@ func (m model) View() string {
 			m.addNewEffortNameTextInput.View(),
 			m.addNewEffortBranchNameTextInput.View(),
 		)
+	case activeViewEffortDetail:
+		titlePrefix := fmt.Sprintf("Effort \"%s\"", m.selectedEffort.Desc)
+		var title string
+		if m.loading {
+			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
+		} else {
+			title = titlePrefix
+		}
+		theEffort := m.selectedEffort
+		// the repos that were read are more current than the ones the efforts list was loaded with
+		if !m.loading {
+			theEffort.Repos = nil
+			for _, d := range m.effortDetails {
+				theEffort.Repos = append(theEffort.Repos, d.theRepo)
+			}
+		}
+		var rows []string
+		cursorRow := 0
+		for i, d := range m.effortDetails {
+			lines := formatEffortRepoDetail(m.selectedEffort, d)
+			if m.cursor == i {
+				cursorRow = len(rows)
+				lines[0] = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render(lines[0])
+			}
+			rows = append(rows, lines...)
+			rows = append(rows, "")
+		}
+		if len(rows) == 0 && !m.loading {
+			rows = append(rows, "no repos yet, e picks them")
+		}
+		display = fmt.Sprintf(
+			"%s\n\n%s\n\n%s\n\n%s",
+			title,
+			strings.Join(formatEffortDetail(theEffort), "\n"),
+			strings.Join(visibleWindow(rows, cursorRow, m.windowHeight-24), "\n"),
+			helpStyle.Render("j/k move • e edit repos • o shell in worktree • O shell in effort directory • d delete • D force delete • esc back"),
+		)
 	case activeViewEditEffort:
 		var availableRepos []string
 		for i, theRepo := range m.effortRepoVisibleSelection {
@@ -72,7 +133,7 @@ func (m model) View() string {
 			}
 			repoTitle := theRepo.Title()
 			if m.listFilterLive || (m.listFilterSet && m.cursor != i) {
-				repoTitle = highlightFoundText(repoTitle, m.listFilterTextInput.Value())
+				repoTitle = lipgloss.StyleRunes(repoTitle, theRepo.matchedIndexes, highlightStyle, lipgloss.NewStyle())
 			}
 			itemDisplay := fmt.Sprintf("%s %s", selectedMarker, repoTitle)
 			itemDisplay = lipgloss.NewStyle().MarginLeft(2).Render(itemDisplay)
@@ -81,7 +142,34 @@ func (m model) View() string {
 			}
 			availableRepos = append(availableRepos, itemDisplay)
 		}
-		titlePrefix := fmt.Sprintf("Add repos to \"%s\"", m.selectedEffort.Desc)
+		cursorRow := m.cursor
+		help := "space toggle • J/K range • a all • n none • i invert • e from effort • u undo • / filter • enter apply • esc back"
+		if m.pickingOtherEffort {
+			// the efforts take the place of the repos until one is picked
+			availableRepos = nil
+			for i, e := range m.otherEffortsWithRepos() {
+				var repoTitles []string
+				for _, r := range e.Repos {
+					repoTitles = append(repoTitles, r.Title())
+				}
+				itemDisplay := lipgloss.NewStyle().MarginLeft(2).Render(fmt.Sprintf("%s: %s", e.Name, strings.Join(repoTitles, ", ")))
+				if m.otherEffortCursor == i {
+					itemDisplay = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render(itemDisplay)
+				}
+				availableRepos = append(availableRepos, itemDisplay)
+			}
+			if len(availableRepos) == 0 {
+				availableRepos = append(availableRepos, lipgloss.NewStyle().MarginLeft(2).Render("no other effort has repos"))
+			}
+			cursorRow = m.otherEffortCursor
+			help = "j/k move • enter add the repos of this effort to the selection • esc back"
+		}
+		titlePrefix := fmt.Sprintf(
+			"Add repos to \"%s\" (%d/%d selected)",
+			m.selectedEffort.Desc,
+			countSelectedRepos(m.repos.Items()),
+			len(m.repos.Items()),
+		)
 		var title string
 		if m.loading {
 			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
@@ -95,10 +183,288 @@ func (m model) View() string {
 			Render(title)
 		textInput := lipgloss.NewStyle().Padding(1).Render(m.listFilterTextInput.View())
 		display = fmt.Sprintf(
-			"%s\n%s\n%s",
+			"%s\n%s\n%s\n\n%s",
 			title,
 			textInput,
-			strings.Join(availableRepos, "\n"),
+			strings.Join(visibleWindow(availableRepos, cursorRow, m.repoPickerHeight()), "\n"),
+			helpStyle.Render(help),
+		)
+	case activeViewDoctor:
+		titlePrefix := "Doctor"
+		var title string
+		if m.loading {
+			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
+		} else {
+			title = titlePrefix
+		}
+		var issues []string
+		for i, issue := range m.doctorIssues {
+			itemDisplay := fmt.Sprintf("%s\n    fix: %s", issue.Problem, issue.FixDescription)
+			itemDisplay = lipgloss.NewStyle().MarginLeft(2).Render(itemDisplay)
+			if m.cursor == i {
+				itemDisplay = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render(itemDisplay)
+			}
+			issues = append(issues, itemDisplay)
+		}
+		if len(issues) == 0 && !m.loading {
+			issues = append(issues, "no issues found")
+		}
+		display = fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			title,
+			strings.Join(issues, "\n"),
+			helpStyle.Render("j/k move • f fix selected • F fix all • r rescan • esc back"),
+		)
+	case activeViewRecover:
+		titlePrefix := "Unfinished operations, interrupted or failed before they completed"
+		var title string
+		if m.loading {
+			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
+		} else {
+			title = titlePrefix
+		}
+		var operations []string
+		for i, j := range m.unfinishedOperations {
+			itemDisplay := lipgloss.NewStyle().MarginLeft(2).Render(strings.TrimSuffix(formatJournal(j), "\n"))
+			if m.cursor == i {
+				itemDisplay = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render(itemDisplay)
+			}
+			operations = append(operations, itemDisplay)
+		}
+		if len(operations) == 0 && !m.loading {
+			operations = append(operations, "nothing left to recover")
+		}
+		display = fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			title,
+			strings.Join(operations, "\n\n"),
+			helpStyle.Render("j/k move • r resume • b roll back • x abandon, leave as is • esc decide later"),
+		)
+	case activeViewCommit:
+		titlePrefix := fmt.Sprintf("Commit \"%s\"", m.selectedEffort.Desc)
+		var title string
+		if m.loading {
+			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
+		} else {
+			title = titlePrefix
+		}
+		var repoChanges []string
+		for i, c := range m.worktreeChanges {
+			selectedMarker := "[ ]"
+			if c.Selected {
+				selectedMarker = "[x]"
+			}
+			lines := []string{fmt.Sprintf("%s %s  %d staged, %d unstaged", selectedMarker, c.theRepo.Title(), len(c.Staged), len(c.Unstaged))}
+			for _, path := range c.Staged {
+				lines = append(lines, "      staged:   "+path)
+			}
+			for _, path := range c.Unstaged {
+				lines = append(lines, "      unstaged: "+path)
+			}
+			itemDisplay := lipgloss.NewStyle().MarginLeft(2).Render(strings.Join(lines, "\n"))
+			if m.cursor == i && !m.commitMessageTextInput.Focused() {
+				itemDisplay = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render(itemDisplay)
+			}
+			repoChanges = append(repoChanges, itemDisplay)
+		}
+		if len(repoChanges) == 0 && !m.loading {
+			repoChanges = append(repoChanges, "this effort has no worktrees")
+		}
+		stageAllMarker := "[ ]"
+		if m.commitStageAll {
+			stageAllMarker = "[x]"
+		}
+		display = fmt.Sprintf(
+			"%s\n\n%s\n\n%s stage all changes\n\n%s\n\n%s",
+			title,
+			strings.Join(repoChanges, "\n"),
+			stageAllMarker,
+			m.commitMessageTextInput.View(),
+			helpStyle.Render("tab switch between message and repos • j/k move • space include repo • a stage all • enter commit • esc back"),
+		)
+	case activeViewExec:
+		display = fmt.Sprintf(
+			"Run a command in every repo of \"%s\"\n%s\n\n%s",
+			m.selectedEffort.Desc,
+			m.execCommandTextInput.View(),
+			helpStyle.Render(fmt.Sprintf("enter run, %d at a time • esc back", config.ExecConcurrency)),
+		)
+	case activeViewSearch:
+		titlePrefix := fmt.Sprintf("Search \"%s\"", m.selectedEffort.Desc)
+		var title string
+		if m.loading {
+			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
+		} else {
+			title = titlePrefix
+		}
+		// group the matches by repo then file, only the match rows can be selected
+		var rows []string
+		cursorRow := 0
+		var currentRepo, currentFile string
+		for i, match := range m.searchMatches {
+			if match.theRepo.Title() != currentRepo {
+				currentRepo = match.theRepo.Title()
+				currentFile = ""
+				rows = append(rows, lipgloss.NewStyle().Bold(true).Render(currentRepo))
+			}
+			if match.File != currentFile {
+				currentFile = match.File
+				rows = append(rows, "  "+currentFile)
+			}
+			row := fmt.Sprintf("    %d: %s", match.Line, strings.TrimSpace(match.Text))
+			if m.cursor == i && !m.searchTextInput.Focused() {
+				cursorRow = len(rows)
+				row = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render(row)
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) == 0 && !m.loading && !m.searchTextInput.Focused() {
+			rows = append(rows, "no matches found")
+		}
+		display = fmt.Sprintf(
+			"%s\n%s\n\n%s\n\n%s",
+			title,
+			m.searchTextInput.View(),
+			strings.Join(visibleWindow(rows, cursorRow, m.windowHeight-14), "\n"),
+			helpStyle.Render("enter search/open in $EDITOR • / edit pattern • j/k move • esc back"),
+		)
+	case activeViewDiff:
+		titlePrefix := fmt.Sprintf("Diff \"%s\" against trunk", m.selectedEffort.Desc)
+		var title string
+		if m.loading {
+			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
+		} else {
+			title = titlePrefix
+		}
+		var rows []string
+		cursorRow := 0
+		for i, node := range getDiffNodes(m.diffs) {
+			d := m.diffs[node.repoIndex]
+			var row string
+			var patch string
+			if node.fileIndex == -1 {
+				marker := "+"
+				if d.Expanded {
+					marker = "-"
+				}
+				row = fmt.Sprintf("%s %s", marker, d.summary())
+			} else {
+				f := d.Files[node.fileIndex]
+				row = fmt.Sprintf("    %s  +%d -%d", f.Path, f.Added, f.Deleted)
+				if f.Expanded {
+					patch = colorizePatch(f.Patch, f.Path)
+				}
+			}
+			if m.cursor == i {
+				cursorRow = len(rows)
+				row = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render(row)
+			}
+			rows = append(rows, row)
+			if patch != "" {
+				rows = append(rows, strings.Split(lipgloss.NewStyle().MarginLeft(6).Render(patch), "\n")...)
+			}
+		}
+		if len(rows) == 0 && !m.loading {
+			rows = append(rows, "this effort has no repos")
+		}
+		display = fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			title,
+			strings.Join(visibleWindow(rows, cursorRow, m.windowHeight-12), "\n"),
+			helpStyle.Render("j/k move • enter expand • e export patch • m export markdown • esc back"),
+		)
+	case activeViewHistory:
+		titlePrefix := "History"
+		var title string
+		if m.loading {
+			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
+		} else {
+			title = titlePrefix
+		}
+		kind := "events and git commands"
+		switch m.historyFilter.Kind {
+		case operationLogEvent:
+			kind = "events only"
+		case operationLogGit:
+			kind = "git commands only"
+		}
+		outcome := "any outcome"
+		if m.historyFilter.FailedOnly {
+			outcome = "failures only"
+		}
+		filters := fmt.Sprintf("%s • %s • %s", kind, outcome, m.historyFilterTextInput.View())
+		var rows []string
+		cursorRow := 0
+		for i, e := range m.historyEntries {
+			row := formatOperationLogEntry(e)
+			if m.cursor == i {
+				cursorRow = len(rows)
+				row = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render(row)
+			} else if e.failed() {
+				row = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(row)
+			}
+			rows = append(rows, row)
+			if m.cursor == i && m.historyShowOutput {
+				output := strings.TrimSpace(e.Output)
+				if output == "" {
+					output = "no output"
+				}
+				rows = append(rows, strings.Split(lipgloss.NewStyle().MarginLeft(6).Render(output), "\n")...)
+			}
+		}
+		if len(rows) == 0 && !m.loading {
+			rows = append(rows, "nothing recorded that matches")
+		}
+		display = fmt.Sprintf(
+			"%s\n%s\n\n%s\n\n%s",
+			title,
+			filters,
+			strings.Join(visibleWindow(rows, cursorRow, m.windowHeight-14), "\n"),
+			helpStyle.Render("j/k move • enter output • t kind • f failures • / search • x export json • esc back"),
+		)
+	case activeViewReport:
+		if m.loading {
+			display = fmt.Sprintf("Working\t%s", m.spinner.View())
+		} else {
+			display = fmt.Sprintf(
+				"%s\n\n%s",
+				m.report,
+				helpStyle.Render("esc back"),
+			)
+		}
+	case activeViewAdopt:
+		titlePrefix := "Adopt branches created outside of efforts"
+		var title string
+		if m.loading {
+			title = fmt.Sprintf("%s\t%s", titlePrefix, m.spinner.View())
+		} else {
+			title = titlePrefix
+		}
+		var candidates []string
+		for i, c := range m.adoptionCandidates {
+			var repoLines []string
+			for _, r := range c.Repos {
+				repoLine := r.theRepo.Title()
+				if r.WorktreeDir != "" {
+					repoLine += " (worktree " + r.WorktreeDir + ")"
+				}
+				repoLines = append(repoLines, "    "+repoLine)
+			}
+			itemDisplay := fmt.Sprintf("%s\n%s", c.BranchName, strings.Join(repoLines, "\n"))
+			itemDisplay = lipgloss.NewStyle().MarginLeft(2).Render(itemDisplay)
+			if m.cursor == i {
+				itemDisplay = lipgloss.NewStyle().Foreground(lipgloss.Color("201")).Render(itemDisplay)
+			}
+			candidates = append(candidates, itemDisplay)
+		}
+		if len(candidates) == 0 && !m.loading {
+			candidates = append(candidates, "no branches found outside of efforts")
+		}
+		display = fmt.Sprintf(
+			"%s\n\n%s\n\n%s",
+			title,
+			strings.Join(candidates, "\n"),
+			helpStyle.Render("j/k move • enter create effort from branch • esc back"),
 		)
 	case activeViewListRepos:
 		display = m.repos.View()
@@ -109,6 +475,9 @@ func (m model) View() string {
 	if m.validationMsg != "" {
 		display += getErrorStyle(m.validationMsg)
 	}
+	if m.statusMsg != "" {
+		display += "\n\n" + helpStyle.Render(m.statusMsg)
+	}
 	return docStyle.Render(display)
 }
 
@@ -117,16 +486,21 @@ func getErrorStyle(errMsg string) string {
 	return fmt.Sprintf("\n\n%v", errorStyle.Render(errMsg))
 }
 
-func highlightFoundText(str string, substr string) string {
-	if !strings.Contains(str, substr) {
-		return str
-	}
-
-	prefixAndSuffix := strings.SplitN(str, substr, 2)
-	before := prefixAndSuffix[0]
-	after := prefixAndSuffix[1]
-
-	highlightedSubstr := highlightStyle.Render(substr)
+// repoPickerHeight is how many repos of the edit effort view fit between its title and filter and the help
+func (m model) repoPickerHeight() int {
+	return max(m.windowHeight-14, 5)
+}
 
-	return before + highlightedSubstr + after
+// visibleWindow returns the rows that fit in height while keeping the cursor row in view
+func visibleWindow(rows []string, cursorRow int, height int) []string {
+	if height < 5 {
+		height = 5
+	}
+	if len(rows) <= height {
+		return rows
+	}
+	start := max(cursorRow-height/2, 0)
+	end := min(start+height, len(rows))
+	start = max(end-height, 0)
+	return rows[start:end]
 }